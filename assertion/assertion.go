@@ -2,93 +2,202 @@ package assertion
 
 import (
 	"bufio"
+	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/types"
 	"sigs.k8s.io/yaml"
 )
 
-var (
-	matcherEqual     = regexp.MustCompile(`^equal (.*)$`)
-	matcherElementOf = regexp.MustCompile(`^be an element of (.*)$`)
-	matcherConsistOf = regexp.MustCompile(`^consist of (.*)$`)
-	matcherNumeric   = regexp.MustCompile(`^(?:be )?([~=<>]{1,2}) (\d+)$`)
-	matcherBool      = regexp.MustCompile(`^be (true|false)$`)
-	matcherContain   = regexp.MustCompile(`^contain (.*)$`)
-	matcherPrefix    = regexp.MustCompile(`^have prefix (.*)$`)
-	matcherSuffix    = regexp.MustCompile(`^have suffix (.*)$`)
-	matcherRegex     = regexp.MustCompile(`^match regex (.*)$`)
-	matcherLen       = regexp.MustCompile(`^have length (\d)$`)
+// quantities may be followed by a unit for readability, e.g. 0.5 cpu
+const (
+	quantityNumber = `[+-]?(?:\d+(?:\.\d*)?|\.\d+)`
+	quantitySuffix = `(?:Ki|Mi|Gi|Ti|Pi|Ei|n|u|m|k|M|G|T|P|E)`
+	quantityUnit   = `(?: (?:cpus?|cores?|bytes?))`
 )
 
-func getWords(in string) (out []interface{}) {
+func init() {
+	RegisterBuiltins(DefaultRegistry)
+}
+
+// RegisterBuiltins registers the built in matchers. A quantity with a suffix
+// or unit is registered before equal so the more specific phrase wins, while
+// numeric comparisons are registered before quantity comparisons so plain
+// numbers are still compared as numbers. Collection matchers take a nested
+// matcher, parsed with r, which should be wrapped in parentheses when it
+// combines matchers.
+func RegisterBuiltins(r *Registry) {
+	r.mustRegisterBuiltin("equal <quantity>", `equal (`+quantityNumber+`(?:`+quantitySuffix+quantityUnit+`?|`+quantityUnit+`))`, equalQuantity)
+	r.mustRegisterBuiltin("equal <value>", `equal (.*)`, equal)
+	r.mustRegisterBuiltin("match regex <regex>", `match regex (.*)`, matchRegex)
+	r.mustRegisterBuiltin("have length <n>", `have length (\d+)`, haveLength)
+	r.mustRegisterBuiltin("contain <substring>", `contain (.*)`, contain)
+	r.mustRegisterBuiltin("have prefix <prefix>", `have prefix (.*)`, havePrefix)
+	r.mustRegisterBuiltin("have suffix <suffix>", `have suffix (.*)`, haveSuffix)
+	r.mustRegisterBuiltin("be <comparator> <number>", `(?:be )?([~=<>]{1,2}) (-?\d+(?:\.\d+)?)`, beNumerically)
+	r.mustRegisterBuiltin("be <comparator> <quantity>", `(?:be )?(==|=|<=|<|>=|>) (`+quantityNumber+quantitySuffix+`?`+quantityUnit+`?)`, beQuantity)
+	r.mustRegisterBuiltin("be true|false", `be (true|false)`, beBool)
+	r.mustRegisterBuiltin("be older|newer than <duration>", `be (older|newer) than (\S+)`, beAged)
+	r.mustRegisterBuiltin("be before|after <timestamp|now>", `be (before|after) (\S+)`, beBeforeOrAfter)
+	r.mustRegisterBuiltin("be within <duration> of <timestamp|now>", `be within (\S+) of (\S+)`, beWithin)
+	r.mustRegisterBuiltin("be an element of <values>", `be an element of (.*)`, beAnElementOf)
+	r.mustRegisterBuiltin("consist of <values>", `consist of (.*)`, consistOf)
+	r.mustRegisterBuiltin("conform to schema file <path>", `conform to schema file (\S+)`, conformToSchemaFile)
+	r.mustRegisterBuiltin("have every element <matcher>", `have every element (.+)`, haveEveryElement(r))
+	r.mustRegisterBuiltin("have an element <matcher>", `have an element (.+)`, haveAnElement(r))
+	r.mustRegisterBuiltin("have key <key> with value <matcher>", `have key ("[^"]*"|'[^']*'|\S+) with value (.+)`, haveKeyWithValue(r))
+	r.mustRegisterBuiltin("have key <key>", `have key ("[^"]*"|'[^']*'|\S+)`, haveKey)
+	r.mustRegisterBuiltin("have at least|most <n> elements", `have at (least|most) (\d+) elements?`, haveElementCount)
+}
+
+func getWords(in string) (out []interface{}, err error) {
 	scanner := bufio.NewScanner(strings.NewReader(in))
 	scanner.Split(bufio.ScanWords)
 	for scanner.Scan() {
 		var word interface{}
-		Expect(yaml.Unmarshal(scanner.Bytes(), &word)).Should(Succeed())
+		if err := yaml.Unmarshal(scanner.Bytes(), &word); err != nil {
+			return nil, err
+		}
 		out = append(out, word)
 	}
 	return
 }
 
-func GetMatcher(text string) types.GomegaMatcher {
-	switch {
-	case matcherEqual.MatchString(text):
-		expectedBytes := []byte(matcherEqual.FindStringSubmatch(text)[1])
-		var expected interface{}
-		Expect(yaml.Unmarshal(expectedBytes, &expected)).Should(Succeed())
-		return BeEquivalentTo(expected)
-
-	case matcherRegex.MatchString(text):
-		expected := matcherRegex.FindStringSubmatch(text)[1]
-		return MatchRegexp(string(expected))
-
-	case matcherLen.MatchString(text):
-		fields := matcherLen.FindStringSubmatch(text)
-		expected, err := strconv.Atoi(string(fields[1]))
-		Expect(err).ShouldNot(HaveOccurred(), "Length must be a positive integer")
-		return HaveLen(expected)
-
-	case matcherContain.MatchString(text):
-		expected := matcherContain.FindStringSubmatch(text)[1]
-		return ContainSubstring(string(expected))
-
-	case matcherPrefix.MatchString(text):
-		expected := matcherContain.FindStringSubmatch(text)[1]
-		return HavePrefix(string(expected))
-
-	case matcherSuffix.MatchString(text):
-		expected := matcherContain.FindStringSubmatch(text)[1]
-		return HaveSuffix(string(expected))
-
-	case matcherNumeric.MatchString(text):
-		fields := matcherNumeric.FindSubmatch([]byte(text))
-		expectedBytes := fields[2]
-		var expected interface{}
-		Expect(yaml.Unmarshal(expectedBytes, &expected)).Should(Succeed())
-		comparator := string(fields[1])
-		return BeNumerically(comparator, expected)
-
-	case matcherBool.MatchString(text):
-		fields := matcherBool.FindStringSubmatch(string(text))
-		if fields[1] == "true" {
-			return BeTrue()
-		}
-		return BeFalse()
+// GetMatcher parses text into a gomega matcher using the DefaultRegistry.
+// Matchers may be combined with and, or, not and parentheses, e.g.
+//
+//	be >= 1 and be <= 3
+//	not (equal Running or equal Succeeded)
+//
+// A *SyntaxError pointing at the offending token is returned when text
+// cannot be parsed.
+func GetMatcher(text string) (types.GomegaMatcher, error) {
+	return DefaultRegistry.GetMatcher(text)
+}
 
-	case matcherElementOf.MatchString(text):
-		fields := matcherElementOf.FindStringSubmatch(string(text))
-		return BeElementOf(getWords(fields[1])...)
+// GetMatcherWithCaptures parses text using the DefaultRegistry, allowing
+// regex matchers to store groups in the returned Captures.
+func GetMatcherWithCaptures(text string) (types.GomegaMatcher, Captures, error) {
+	return DefaultRegistry.GetMatcherWithCaptures(text)
+}
+
+func equalQuantity(args ...string) (types.GomegaMatcher, error) {
+	expected, err := parseQuantity(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return BeQuantity("==", expected), nil
+}
 
-	case matcherConsistOf.MatchString(text):
-		fields := matcherConsistOf.FindStringSubmatch(string(text))
-		return ConsistOf(getWords(fields[1])...)
+func equal(args ...string) (types.GomegaMatcher, error) {
+	var expected interface{}
+	if err := yaml.Unmarshal([]byte(args[0]), &expected); err != nil {
+		return nil, err
+	}
+	return BeEquivalentTo(expected), nil
+}
+
+func matchRegex(args ...string) (types.GomegaMatcher, error) {
+	expr := unquote(args[0])
+	if _, err := regexp.Compile(expr); err != nil {
+		return nil, err
+	}
+	return MatchRegexp(expr), nil
+}
+
+func haveLength(args ...string) (types.GomegaMatcher, error) {
+	expected, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("length must be a positive integer")
+	}
+	return HaveLen(expected), nil
+}
+
+func contain(args ...string) (types.GomegaMatcher, error) {
+	return ContainSubstring(unquote(args[0])), nil
+}
+
+func havePrefix(args ...string) (types.GomegaMatcher, error) {
+	return HavePrefix(unquote(args[0])), nil
+}
+
+func haveSuffix(args ...string) (types.GomegaMatcher, error) {
+	return HaveSuffix(unquote(args[0])), nil
+}
+
+func beNumerically(args ...string) (types.GomegaMatcher, error) {
+	var expected interface{}
+	if err := yaml.Unmarshal([]byte(args[1]), &expected); err != nil {
+		return nil, err
+	}
+	return BeNumerically(args[0], expected), nil
+}
+
+func beQuantity(args ...string) (types.GomegaMatcher, error) {
+	expected, err := parseQuantity(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return BeQuantity(args[0], expected), nil
+}
+
+func beBool(args ...string) (types.GomegaMatcher, error) {
+	if args[0] == "true" {
+		return BeTrue(), nil
+	}
+	return BeFalse(), nil
+}
+
+func beAged(args ...string) (types.GomegaMatcher, error) {
+	d, err := time.ParseDuration(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if args[0] == "older" {
+		return BeOlderThan(d), nil
+	}
+	return BeNewerThan(d), nil
+}
+
+func beBeforeOrAfter(args ...string) (types.GomegaMatcher, error) {
+	t, err := parseTime(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if args[0] == "before" {
+		return BeBefore(t), nil
+	}
+	return BeAfter(t), nil
+}
+
+func beWithin(args ...string) (types.GomegaMatcher, error) {
+	d, err := time.ParseDuration(args[0])
+	if err != nil {
+		return nil, err
+	}
+	t, err := parseTime(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return BeWithin(d, t), nil
+}
+
+func beAnElementOf(args ...string) (types.GomegaMatcher, error) {
+	words, err := getWords(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return BeElementOf(words...), nil
+}
 
-	default:
-		panic("unrecognised assertion")
+func consistOf(args ...string) (types.GomegaMatcher, error) {
+	words, err := getWords(args[0])
+	if err != nil {
+		return nil, err
 	}
+	return ConsistOf(words...), nil
 }
@@ -0,0 +1,76 @@
+package assertion
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"github.com/onsi/gomega/format"
+)
+
+// SatisfyCEL returns a matcher which succeeds if the CEL expression
+// evaluates to true with the object bound to self, as in Kubernetes
+// validation rules. An error is returned if the expression does not compile
+// or cannot return a bool.
+func SatisfyCEL(expression string) (*CELMatcher, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("self", cel.DynType),
+		ext.Strings(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("invalid CEL expression: %w", issues.Err())
+	}
+	if t := ast.OutputType(); t != cel.BoolType && t != cel.DynType {
+		return nil, fmt.Errorf("CEL expression must evaluate to a bool, not %s", t)
+	}
+
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CELMatcher{
+		Expression: expression,
+		program:    program,
+	}, nil
+}
+
+type CELMatcher struct {
+	Expression string
+
+	program cel.Program
+}
+
+func (m *CELMatcher) Match(actual interface{}) (bool, error) {
+	var self interface{}
+	switch v := actual.(type) {
+	case unstructuredContent:
+		self = v.UnstructuredContent()
+	default:
+		self = v
+	}
+
+	val, _, err := m.program.Eval(map[string]interface{}{"self": self})
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", m.Expression, err)
+	}
+
+	result, ok := val.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("%s evaluated to %v, not a bool", m.Expression, val.Value())
+	}
+	return result, nil
+}
+
+func (m *CELMatcher) FailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected\n%s\nto evaluate to true", format.Object(m.Expression, 1))
+}
+
+func (m *CELMatcher) NegatedFailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected\n%s\nto evaluate to false", format.Object(m.Expression, 1))
+}
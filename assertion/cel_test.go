@@ -0,0 +1,92 @@
+package assertion
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSatisfyCEL(t *testing.T) {
+	pod := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web-0"},
+		"spec":     map[string]interface{}{"replicas": int64(3)},
+	}}
+
+	tests := []struct {
+		expression string
+		actual     interface{}
+		match      bool
+	}{
+		{"self == 3", 3, true},
+		{"self > 3", 3, false},
+		{"self.startsWith('web')", "web-0", true},
+		{"self.spec.replicas >= 1", pod, true},
+		{"self.metadata.name.split('-')[1] == '1'", pod, false},
+		{"size(self) == 2", []interface{}{"a", "b"}, true},
+		{"has(self.spec)", map[string]interface{}{"spec": map[string]interface{}{}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			m, err := SatisfyCEL(tt.expression)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			match, err := m.Match(tt.actual)
+			if err != nil {
+				t.Fatalf("unexpected match error: %s", err)
+			}
+			if match != tt.match {
+				t.Errorf("expected match %t for %v, got %t", tt.match, tt.actual, match)
+			}
+		})
+	}
+}
+
+func TestSatisfyCELCompileError(t *testing.T) {
+	tests := []struct {
+		expression string
+		err        string
+	}{
+		{"self ==", "invalid CEL expression"},
+		{"unknown > 1", "invalid CEL expression"},
+		{"'a' + 1", "invalid CEL expression"},
+		{"'a' + 'b'", "CEL expression must evaluate to a bool, not string"},
+		{"1 + 2", "CEL expression must evaluate to a bool, not int"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			_, err := SatisfyCEL(tt.expression)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+				t.Errorf("expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestSatisfyCELEvalError(t *testing.T) {
+	tests := []struct {
+		expression string
+		actual     interface{}
+		err        string
+	}{
+		{"self.spec.replicas > 1", map[string]interface{}{}, "self.spec.replicas > 1: no such key: spec"},
+		{"self / 0 == 1", 1, "self / 0 == 1: division by zero"},
+		{"self", "yes", "self evaluated to yes, not a bool"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			m, err := SatisfyCEL(tt.expression)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			_, err = m.Match(tt.actual)
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
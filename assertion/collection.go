@@ -0,0 +1,87 @@
+package assertion
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+// haveEveryElement matches a list where every element satisfies a nested
+// matcher from r
+func haveEveryElement(r *Registry) MatcherFunc {
+	return func(args ...string) (types.GomegaMatcher, error) {
+		m, err := r.GetMatcher(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return HaveEach(m), nil
+	}
+}
+
+// haveAnElement matches a list where at least one element satisfies a
+// nested matcher from r
+func haveAnElement(r *Registry) MatcherFunc {
+	return func(args ...string) (types.GomegaMatcher, error) {
+		m, err := r.GetMatcher(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return ContainElement(m), nil
+	}
+}
+
+// haveKeyWithValue matches a map with the key whose value satisfies a
+// nested matcher from r
+func haveKeyWithValue(r *Registry) MatcherFunc {
+	return func(args ...string) (types.GomegaMatcher, error) {
+		m, err := r.GetMatcher(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return HaveKeyWithValue(unquote(args[0]), m), nil
+	}
+}
+
+func haveKey(args ...string) (types.GomegaMatcher, error) {
+	return HaveKey(unquote(args[0])), nil
+}
+
+func haveElementCount(args ...string) (types.GomegaMatcher, error) {
+	n, err := strconv.Atoi(args[1])
+	if err != nil {
+		return nil, fmt.Errorf("number of elements must be a positive integer")
+	}
+	comparator := ">="
+	if args[0] == "most" {
+		comparator = "<="
+	}
+	return WithTransform(length, BeNumerically(comparator, n)), nil
+}
+
+// length returns the number of elements in a list or map
+func length(actual interface{}) (int, error) {
+	v := reflect.ValueOf(actual)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.String:
+		return v.Len(), nil
+	default:
+		return 0, fmt.Errorf("expected a list or map. Got:\n%s", format.Object(actual, 1))
+	}
+}
+
+// unquote returns a value quoted to stop its words being read as operators
+// without the quotes. A double quoted value may escape its quotes with \".
+func unquote(s string) string {
+	if len(s) > 1 && s[0] == '"' && s[len(s)-1] == '"' {
+		return strings.ReplaceAll(s[1:len(s)-1], `\"`, `"`)
+	}
+	if len(s) > 1 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return s[1 : len(s)-1]
+	}
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,102 @@
+package assertion
+
+import (
+	"testing"
+)
+
+func TestCollectionMatchers(t *testing.T) {
+	tests := []struct {
+		text   string
+		actual interface{}
+		match  bool
+		err    bool
+	}{
+		{text: "have every element (be >= 1)", actual: []interface{}{1, 2}, match: true},
+		{text: "have every element (be >= 1)", actual: []interface{}{0, 2}, match: false},
+		{text: "have every element have prefix web", actual: map[string]interface{}{"a": "web-0", "b": "web-1"}, match: true},
+		{text: "have every element (be >= 1)", actual: []interface{}{}, err: true},
+		{text: "have every element (be >= 1)", actual: 1, err: true},
+
+		{text: "have an element (equal x or equal z)", actual: []interface{}{"a", "z"}, match: true},
+		{text: "have an element (equal x or equal z)", actual: []interface{}{"a", "b"}, match: false},
+		{text: "have an element equal x", actual: []interface{}{}, match: false},
+		{text: "have an element equal x", actual: "x", err: true},
+
+		{text: "have key app", actual: map[string]interface{}{"app": "web"}, match: true},
+		{text: "have key 'app.kubernetes.io/name'", actual: map[string]interface{}{"app.kubernetes.io/name": "web"}, match: true},
+		{text: "have key app", actual: map[string]interface{}{}, match: false},
+		{text: "have key app", actual: []interface{}{"app"}, err: true},
+
+		{text: "have key app with value equal web", actual: map[string]interface{}{"app": "web"}, match: true},
+		{text: `have key "tier" with value (equal web or equal api)`, actual: map[string]interface{}{"tier": "api"}, match: true},
+		{text: "have key app with value equal web", actual: map[string]interface{}{"app": "api"}, match: false},
+		{text: "have key app with value equal web", actual: map[string]interface{}{}, match: false},
+		{text: "have key app with value equal web", actual: "app", err: true},
+
+		{text: "have at least 2 elements", actual: []interface{}{1, 2}, match: true},
+		{text: "have at least 2 elements", actual: map[string]interface{}{"a": 1}, match: false},
+		{text: "have at least 1 element", actual: []interface{}{}, match: false},
+		{text: "have at most 1 element", actual: []interface{}{}, match: true},
+		{text: "have at most 1 element", actual: []interface{}{1, 2}, match: false},
+		{text: "have at most 1 element", actual: 1, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			m, err := GetMatcher(tt.text)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			match, err := m.Match(tt.actual)
+			if tt.err {
+				if err == nil {
+					t.Errorf("expected an error for %v", tt.actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected match error: %s", err)
+			}
+			if match != tt.match {
+				t.Errorf("expected match %t for %v, got %t", tt.match, tt.actual, match)
+			}
+		})
+	}
+}
+
+func TestCollectionMatchersNestedError(t *testing.T) {
+	for _, text := range []string{
+		"have every element (bogus)",
+		"have an element (equal x or)",
+		"have key app with value bogus",
+	} {
+		t.Run(text, func(t *testing.T) {
+			if _, err := GetMatcher(text); err == nil {
+				t.Errorf("expected an error for the nested matcher")
+			}
+		})
+	}
+}
+
+func TestUnquote(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{`"a or b"`, "a or b"},
+		{`'a or b'`, "a or b"},
+		{`"say \"hi\""`, `say "hi"`},
+		{`'say \'hi'`, `say \'hi`},
+		{` plain `, "plain"},
+		{`"`, `"`},
+		{`"half`, `"half`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if out := unquote(tt.in); out != tt.out {
+				t.Errorf("expected %q, got %q", tt.out, out)
+			}
+		})
+	}
+}
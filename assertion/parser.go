@@ -0,0 +1,384 @@
+package assertion
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
+)
+
+// The matcher grammar combines the phrases in a Registry with the
+// and, or and not keywords. Parentheses group sub expressions, and quoting a
+// value prevents any keyword inside it from being treated as an operator.
+//
+//	expr    = or
+//	or      = and { "or" and }
+//	and     = unary { "and" unary }
+//	unary   = "not" unary | "(" expr ")" | matcher
+//	matcher = word { word }
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	start int
+	end   int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of input"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// SyntaxError is returned when a matcher cannot be parsed. Pos is the byte
+// offset of the offending token within Text.
+type SyntaxError struct {
+	Text string
+	Pos  int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d\n  %s\n  %s^", e.Msg, e.Pos, e.Text, strings.Repeat(" ", e.Pos))
+}
+
+// lex splits text into tokens. Parentheses are only treated as grouping when
+// they lead an operand or are left unbalanced at the end of a word, so
+// matcher values such as regular expressions can still contain balanced
+// ones. A closing parenthesis with no group to close is a syntax error. A
+// parenthesised group within a matcher phrase is kept as one word so it can
+// hold a nested matcher, e.g. have every element (be >= 1 and be <= 3).
+func lex(text string) ([]token, error) {
+	var tokens []token
+	expectOperand := true
+	depth := 0
+
+	i := 0
+	for i < len(text) {
+		if text[i] == ' ' || text[i] == '\t' {
+			i++
+			continue
+		}
+
+		if expectOperand && text[i] == '(' {
+			tokens = append(tokens, token{kind: tokenOpen, text: "(", start: i, end: i + 1})
+			depth++
+			i++
+			continue
+		}
+
+		start := i
+		if text[i] == '(' {
+			// parentheses within a matcher phrase hold a nested matcher, so
+			// keep the balanced group in this word
+			if end := closingParen(text, i); end > 0 {
+				i = end
+			}
+		} else if text[i] == '"' || text[i] == '\'' {
+			quote := text[i]
+			i++
+			for i < len(text) && text[i] != quote {
+				if text[i] == '\\' && quote == '"' {
+					i++
+				}
+				i++
+			}
+			if i >= len(text) {
+				return nil, &SyntaxError{Text: text, Pos: start, Msg: "unterminated quoted value"}
+			}
+			i++
+		}
+		for i < len(text) && text[i] != ' ' && text[i] != '\t' {
+			i++
+		}
+		word := text[start:i]
+
+		// closing parentheses not opened within the word close groups
+		closing := 0
+		for closing < len(word) && word[len(word)-1-closing] == ')' {
+			closing++
+		}
+		excess := strings.Count(word, ")") - strings.Count(word, "(")
+		if excess < closing {
+			closing = excess
+		}
+		if closing < 0 {
+			closing = 0
+		}
+		if closing > depth {
+			pos := start + len(word) - closing + depth
+			return nil, &SyntaxError{Text: text, Pos: pos, Msg: `unexpected ")" without a matching "("`}
+		}
+		word = word[:len(word)-closing]
+
+		if word != "" {
+			tok := token{kind: tokenWord, text: word, start: start, end: start + len(word)}
+			switch {
+			case word == "and" && nextWord(text, i) != "store":
+				tok.kind = tokenAnd
+			case word == "or":
+				tok.kind = tokenOr
+			case word == "not" && expectOperand:
+				tok.kind = tokenNot
+			}
+			tokens = append(tokens, tok)
+			expectOperand = tok.kind != tokenWord
+		}
+
+		for c := 0; c < closing; c++ {
+			pos := start + len(word) + c
+			tokens = append(tokens, token{kind: tokenClose, text: ")", start: pos, end: pos + 1})
+			depth--
+			expectOperand = false
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, start: len(text), end: len(text)})
+	return tokens, nil
+}
+
+// nextWord returns the word following position i
+func nextWord(text string, i int) string {
+	fields := strings.Fields(text[i:])
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// closingParen returns the index after the parenthesis which closes the one
+// at start, or -1 if it is never closed.
+func closingParen(text string, start int) int {
+	depth := 0
+	for i := start; i < len(text); i++ {
+		switch text[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+type parser struct {
+	registry *Registry
+	text     string
+	tokens   []token
+	pos      int
+
+	// captures receives values stored by matchers, storing is not allowed
+	// when it is nil
+	captures Captures
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, a ...interface{}) error {
+	return &SyntaxError{Text: p.text, Pos: t.start, Msg: fmt.Sprintf(format, a...)}
+}
+
+// withoutCaptures parses with storing disabled, used where a matcher may
+// succeed or fail without the whole assertion doing the same.
+func (p *parser) withoutCaptures(parse func() (types.GomegaMatcher, error)) (types.GomegaMatcher, error) {
+	captures := p.captures
+	p.captures = nil
+	defer func() { p.captures = captures }()
+	return parse()
+}
+
+// hasAlternatives reports whether an or follows within the current group
+func (p *parser) hasAlternatives() bool {
+	depth := 0
+	for _, t := range p.tokens[p.pos:] {
+		switch t.kind {
+		case tokenOpen:
+			depth++
+		case tokenClose:
+			if depth == 0 {
+				return false
+			}
+			depth--
+		case tokenOr:
+			if depth == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *parser) parseOr() (types.GomegaMatcher, error) {
+	if p.captures != nil && p.hasAlternatives() {
+		// only some of the alternatives need to match
+		return p.withoutCaptures(p.parseOr)
+	}
+
+	m, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	matchers := []types.GomegaMatcher{m}
+	for p.peek().kind == tokenOr {
+		p.next()
+		m, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	if len(matchers) == 1 {
+		return matchers[0], nil
+	}
+	return SatisfyAny(matchers...), nil
+}
+
+func (p *parser) parseAnd() (types.GomegaMatcher, error) {
+	m, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	matchers := []types.GomegaMatcher{m}
+	for p.peek().kind == tokenAnd {
+		p.next()
+		m, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	if len(matchers) == 1 {
+		return matchers[0], nil
+	}
+	return SatisfyAll(matchers...), nil
+}
+
+func (p *parser) parseUnary() (types.GomegaMatcher, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokenNot:
+		p.next()
+		m, err := p.withoutCaptures(p.parseUnary)
+		if err != nil {
+			return nil, err
+		}
+		return Not(m), nil
+
+	case tokenOpen:
+		p.next()
+		m, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if c := p.next(); c.kind != tokenClose {
+			return nil, p.errorf(c, "expected \")\" to close \"(\" at position %d but found %s", t.start, c)
+		}
+		return m, nil
+
+	case tokenWord:
+		first := p.next()
+		last := first
+		for p.peek().kind == tokenWord {
+			last = p.next()
+		}
+		text := p.text[first.start:last.end]
+		if store := storeSuffix.FindStringSubmatchIndex(text); store != nil {
+			return p.parseStore(text, first.start, store)
+		}
+		m, err := p.registry.leaf(text)
+		var nested *SyntaxError
+		if errors.As(err, &nested) {
+			// point at the token within the nested matcher
+			if offset := strings.LastIndex(text, nested.Text); offset >= 0 {
+				return nil, &SyntaxError{Text: p.text, Pos: first.start + offset + nested.Pos, Msg: nested.Msg}
+			}
+		}
+		if err != nil {
+			return nil, p.errorf(first, "%s", err)
+		}
+		return m, nil
+
+	default:
+		return nil, p.errorf(t, "expected a matcher but found %s", t)
+	}
+}
+
+// parseStore parses a regex matcher which stores one of its groups, the
+// indexes are the submatches of storeSuffix within text.
+func (p *parser) parseStore(text string, start int, store []int) (types.GomegaMatcher, error) {
+	storeToken := token{start: start + store[3] + 1}
+	if p.captures == nil {
+		return nil, p.errorf(storeToken, "values can only be stored by the matchers of a step assertion which must all succeed, not under not or or")
+	}
+
+	matcherText := text[store[2]:store[3]]
+	fields := matcherRegexStore.FindStringSubmatch(matcherText)
+	if fields == nil {
+		return nil, p.errorf(token{start: start}, "only match regex can store a group, not %q", matcherText)
+	}
+	expr := unquote(fields[1])
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, p.errorf(token{start: start}, "%s", err)
+	}
+
+	group, _ := strconv.Atoi(text[store[4]:store[5]])
+	if group > re.NumSubexp() {
+		return nil, p.errorf(token{start: start + store[4]}, "match regex %s has no group %d", expr, group)
+	}
+
+	return &storeMatcher{
+		GomegaMatcher: MatchRegexp(expr),
+		regexp:        re,
+		group:         group,
+		name:          text[store[6]:store[7]],
+		captures:      p.captures,
+	}, nil
+}
+
+func parse(r *Registry, text string, captures Captures) (types.GomegaMatcher, error) {
+	tokens, err := lex(text)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{registry: r, text: text, tokens: tokens, captures: captures}
+	m, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "unexpected %s", t)
+	}
+	return m, nil
+}
@@ -0,0 +1,123 @@
+package assertion
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGetMatcher(t *testing.T) {
+	tests := []struct {
+		text   string
+		actual interface{}
+		match  bool
+	}{
+		{"equal 1 or equal 2 and equal 3", 1, true},
+		{"(equal 1 or equal 2) and equal 3", 1, false},
+		{"equal 3 and equal 3 or equal 1", 1, true},
+		{"not equal 1 and equal 2", 2, true},
+		{"not equal 1 or equal 1", 1, true},
+		{"not (equal 1 or equal 2)", 3, true},
+		{"not (equal 1 or equal 2)", 2, false},
+		{"not not equal 1", 1, true},
+		{"((equal 1))", 1, true},
+		{`equal "a and b"`, "a and b", true},
+		{`equal 'x or y'`, "x or y", true},
+		{"match regex ^(a|b)$", "b", true},
+		{"match regex ^(a|b)$ and not equal a", "a", false},
+		{"have every element (be >= 1 and be <= 3)", []interface{}{1, 2, 3}, true},
+		{"have every element (be >= 1 and be <= 3)", []interface{}{1, 4}, false},
+		{"(have an element (equal x)) or equal y", []interface{}{"x"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			m, err := GetMatcher(tt.text)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			match, err := m.Match(tt.actual)
+			if err != nil {
+				t.Fatalf("unexpected match error: %s", err)
+			}
+			if match != tt.match {
+				t.Errorf("expected match %t for %v, got %t", tt.match, tt.actual, match)
+			}
+		})
+	}
+}
+
+func TestGetMatcherQuotedValues(t *testing.T) {
+	tests := []struct {
+		text   string
+		actual string
+		match  bool
+	}{
+		{`equal "rock and roll"`, "rock and roll", true},
+		{`contain "rock and roll"`, "I like rock and roll music", true},
+		{`contain 'rock and roll'`, "I like rock and roll music", true},
+		{`contain "rock and roll"`, "I like rock music", false},
+		{`have prefix "a or"`, "a or b", true},
+		{`have prefix 'a or'`, "a or b", true},
+		{`have prefix "a or"`, "b or a", false},
+		{`have suffix "or not"`, "to be or not", true},
+		{`have suffix 'or not'`, "to be or not", true},
+		{`have suffix "or not"`, "or not to be", false},
+		{`match regex "^a b$"`, "a b", true},
+		{`match regex '^a b$'`, "a b", true},
+		{`match regex "^a b$"`, "a  b", false},
+		{`contain "say \"hi\""`, `they say "hi"`, true},
+		{`contain "a and b" and have prefix "x or"`, "x or a and b", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			m, err := GetMatcher(tt.text)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			match, err := m.Match(tt.actual)
+			if err != nil {
+				t.Fatalf("unexpected match error: %s", err)
+			}
+			if match != tt.match {
+				t.Errorf("expected match %t for %q, got %t", tt.match, tt.actual, match)
+			}
+		})
+	}
+}
+
+func TestGetMatcherSyntaxError(t *testing.T) {
+	tests := []struct {
+		text string
+		pos  int
+		msg  string
+	}{
+		{"equal a )", 8, `unexpected ")" without a matching "("`},
+		{"(equal a))", 9, `unexpected ")" without a matching "("`},
+		{"equal a) or equal b", 7, `unexpected ")" without a matching "("`},
+		{"(equal a", 8, `expected ")" to close "(" at position 0 but found end of input`},
+		{"equal a and", 11, "expected a matcher but found end of input"},
+		{"and equal a", 0, `expected a matcher but found "and"`},
+		{"equal a or or equal b", 11, `expected a matcher but found "or"`},
+		{"()", 1, `expected a matcher but found ")"`},
+		{`equal "a`, 6, "unterminated quoted value"},
+		{"bogus matcher", 0, `unrecognised matcher "bogus matcher"`},
+		{"equal a and bogus", 12, `unrecognised matcher "bogus"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			_, err := GetMatcher(tt.text)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("expected a *SyntaxError, got %v", err)
+			}
+			if syntaxErr.Pos != tt.pos {
+				t.Errorf("expected position %d, got %d: %s", tt.pos, syntaxErr.Pos, err)
+			}
+			if syntaxErr.Msg != tt.msg {
+				t.Errorf("expected message %q, got %q", tt.msg, syntaxErr.Msg)
+			}
+		})
+	}
+}
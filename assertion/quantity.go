@@ -0,0 +1,75 @@
+package assertion
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/onsi/gomega/format"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// BeQuantity succeeds if actual, parsed as a resource.Quantity, compares to
+// expected using one of the comparators ==, =, <, <=, > or >=.
+func BeQuantity(comparator string, expected resource.Quantity) *QuantityMatcher {
+	return &QuantityMatcher{
+		Comparator: comparator,
+		Expected:   expected,
+	}
+}
+
+type QuantityMatcher struct {
+	Comparator string
+	Expected   resource.Quantity
+}
+
+func (m *QuantityMatcher) Match(actual interface{}) (bool, error) {
+	q, err := toQuantity(actual)
+	if err != nil {
+		return false, err
+	}
+
+	cmp := q.Cmp(m.Expected)
+	switch m.Comparator {
+	case "==", "=":
+		return cmp == 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	default:
+		return false, fmt.Errorf("unknown comparator %q for a quantity", m.Comparator)
+	}
+}
+
+func (m *QuantityMatcher) FailureMessage(actual interface{}) string {
+	return format.Message(actual, fmt.Sprintf("to be %s", m.Comparator), m.Expected.String())
+}
+
+func (m *QuantityMatcher) NegatedFailureMessage(actual interface{}) string {
+	return format.Message(actual, fmt.Sprintf("not to be %s", m.Comparator), m.Expected.String())
+}
+
+// parseQuantity parses an expected quantity, ignoring any trailing unit
+func parseQuantity(text string) (resource.Quantity, error) {
+	return resource.ParseQuantity(strings.Fields(text)[0])
+}
+
+// toQuantity converts a value returned by a JSONPath into a resource.Quantity
+func toQuantity(actual interface{}) (resource.Quantity, error) {
+	switch v := actual.(type) {
+	case resource.Quantity:
+		return v, nil
+	case *resource.Quantity:
+		return *v, nil
+	case string:
+		return resource.ParseQuantity(v)
+	case int, int32, int64, uint, uint32, uint64, float32, float64:
+		return resource.ParseQuantity(fmt.Sprint(v))
+	default:
+		return resource.Quantity{}, fmt.Errorf("QuantityMatcher expects a quantity, string or number. Got:\n%s", format.Object(actual, 1))
+	}
+}
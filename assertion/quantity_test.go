@@ -0,0 +1,75 @@
+package assertion
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestQuantityMatchers(t *testing.T) {
+	half := resource.MustParse("500m")
+
+	tests := []struct {
+		text     string
+		actual   interface{}
+		match    bool
+		matchErr bool
+	}{
+		{"equal 1Gi", "1024Mi", true, false},
+		{"equal 1Gi", "1G", false, false},
+		{"equal 500m", "0.5", true, false},
+		{"equal 0.5 cpu", "500m", true, false},
+		{"equal 2 cores", 2, true, false},
+		{"equal 1 byte", "1", true, false},
+		{"be > 1Gi", "1025Mi", true, false},
+		{"be > 1Gi", "1Gi", false, false},
+		{">= 1Gi", "1Gi", true, false},
+		{"be < 100m", 0.05, true, false},
+		{"be <= 2 cpus", "2000m", true, false},
+		{"be = 500m", &half, true, false},
+		{"be == 500m", half, true, false},
+		{"be > -1", -0.5, true, false},
+		{"be > -1m", "-500m", false, false},
+		{"be < .5", "499m", true, false},
+		{"be >= 1Ki", int64(1024), true, false},
+		{"be >= 1Ki", "not a quantity", false, true},
+		{"be >= 1Ki", nil, false, true},
+		{"be >= 1Ki", []interface{}{1}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			m, err := GetMatcher(tt.text)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			match, err := m.Match(tt.actual)
+			if tt.matchErr {
+				if err == nil {
+					t.Fatalf("expected an error matching %v", tt.actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected match error: %s", err)
+			}
+			if match != tt.match {
+				t.Errorf("expected match %t for %v, got %t", tt.match, tt.actual, match)
+			}
+		})
+	}
+}
+
+func TestQuantityMatcherUnknownComparator(t *testing.T) {
+	if _, err := BeQuantity("~", resource.MustParse("1")).Match("1"); err == nil {
+		t.Error("expected an error for an unknown comparator")
+	}
+}
+
+func TestQuantityMatcherMessage(t *testing.T) {
+	m := BeQuantity(">", resource.MustParse("1Gi"))
+	expected := "Expected\n    <string>: 512Mi\nto be >\n    <string>: 1Gi"
+	if msg := m.FailureMessage("512Mi"); msg != expected {
+		t.Errorf("unexpected failure message:\n%s", msg)
+	}
+}
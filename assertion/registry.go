@@ -0,0 +1,176 @@
+package assertion
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/onsi/gomega/types"
+)
+
+// MatcherFunc returns a matcher from the submatches of a matcher phrase.
+type MatcherFunc func(args ...string) (types.GomegaMatcher, error)
+
+// MatcherDefinition describes a matcher phrase known to a Registry.
+type MatcherDefinition struct {
+	// Usage is a human readable form of the phrase, e.g. "equal <value>"
+	Usage string
+	// Pattern is the regular expression the whole phrase must match
+	Pattern string
+
+	regexp  *regexp.Regexp
+	fn      MatcherFunc
+	builtin bool
+}
+
+// placeholder matches the <value> placeholders in a usage
+var placeholder = regexp.MustCompile(`<[^>]*>`)
+
+// Registry holds the matcher phrases which can be used in assertions.
+// Phrases added with Register are tried before the built in phrases, each in
+// the order they were registered, and the first whose pattern matches is
+// used. A registered phrase can therefore extend a built in one, e.g.
+// "contain exactly <n> items" is used before "contain <substring>".
+type Registry struct {
+	lock        sync.RWMutex
+	definitions []*MatcherDefinition
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// DefaultRegistry holds the built in matchers and is used by GetMatcher.
+var DefaultRegistry = NewRegistry()
+
+// Register adds a matcher phrase to the DefaultRegistry.
+func Register(usage, pattern string, fn MatcherFunc) error {
+	return DefaultRegistry.Register(usage, pattern, fn)
+}
+
+// Matchers returns the matcher phrases in the DefaultRegistry.
+func Matchers() []MatcherDefinition {
+	return DefaultRegistry.Matchers()
+}
+
+// Register adds a matcher phrase. pattern is anchored so it must match the
+// whole phrase, and its submatches are passed to fn. An error is returned if
+// the pattern is invalid, the usage or pattern is already registered, or an
+// earlier registered phrase would shadow it. A phrase is shadowed when an
+// earlier pattern matches its usage with each <placeholder> replaced by a
+// value, e.g. "equal to the <value>" is shadowed by "equal to <value>".
+func (r *Registry) Register(usage, pattern string, fn MatcherFunc) error {
+	return r.register(usage, pattern, fn, false)
+}
+
+// register adds a matcher phrase, checking it is not shadowed by an earlier
+// phrase which is tried before it. Built in phrases are tried after those
+// added with Register, so each is only checked against its own kind.
+func (r *Registry) register(usage, pattern string, fn MatcherFunc, builtin bool) error {
+	if usage == "" {
+		return fmt.Errorf("matcher %q must have a usage", pattern)
+	}
+	if fn == nil {
+		return fmt.Errorf("matcher %q must have a MatcherFunc", usage)
+	}
+
+	re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+	if err != nil {
+		return fmt.Errorf("matcher %q has an invalid pattern: %w", usage, err)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	example := placeholder.ReplaceAllString(usage, "1")
+	for _, d := range r.definitions {
+		if d.Usage == usage {
+			return fmt.Errorf("matcher %q is already registered", usage)
+		}
+		if d.Pattern == pattern {
+			return fmt.Errorf("matcher %q conflicts with %q, both use the pattern %q", usage, d.Usage, pattern)
+		}
+		if d.builtin == builtin && d.regexp.MatchString(example) {
+			return fmt.Errorf("matcher %q would never be used, %q is tried first and matches %q", usage, d.Usage, example)
+		}
+	}
+
+	r.definitions = append(r.definitions, &MatcherDefinition{
+		Usage:   usage,
+		Pattern: pattern,
+		regexp:  re,
+		fn:      fn,
+		builtin: builtin,
+	})
+	return nil
+}
+
+// MustRegister is like Register but panics on error.
+func (r *Registry) MustRegister(usage, pattern string, fn MatcherFunc) {
+	if err := r.Register(usage, pattern, fn); err != nil {
+		panic(err)
+	}
+}
+
+func (r *Registry) mustRegisterBuiltin(usage, pattern string, fn MatcherFunc) {
+	if err := r.register(usage, pattern, fn, true); err != nil {
+		panic(err)
+	}
+}
+
+// ordered returns the definitions in the order they are tried
+func (r *Registry) ordered() []*MatcherDefinition {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	definitions := make([]*MatcherDefinition, 0, len(r.definitions))
+	for _, builtin := range []bool{false, true} {
+		for _, d := range r.definitions {
+			if d.builtin == builtin {
+				definitions = append(definitions, d)
+			}
+		}
+	}
+	return definitions
+}
+
+// Matchers returns the registered matcher phrases in the order they are
+// tried.
+func (r *Registry) Matchers() []MatcherDefinition {
+	ordered := r.ordered()
+	definitions := make([]MatcherDefinition, len(ordered))
+	for i, d := range ordered {
+		definitions[i] = MatcherDefinition{Usage: d.Usage, Pattern: d.Pattern}
+	}
+	return definitions
+}
+
+// GetMatcher parses text, which may combine the registered matcher phrases
+// with and, or, not and parentheses, into a gomega matcher.
+func (r *Registry) GetMatcher(text string) (types.GomegaMatcher, error) {
+	return parse(r, text, nil)
+}
+
+// GetMatcherWithCaptures is like GetMatcher but also allows regex matchers to
+// store a group, e.g. match regex v(\d+) and store group 1 as version. The
+// stored values are added to the returned Captures each time the matcher
+// succeeds.
+func (r *Registry) GetMatcherWithCaptures(text string) (types.GomegaMatcher, Captures, error) {
+	captures := Captures{}
+	m, err := parse(r, text, captures)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, captures, nil
+}
+
+// leaf returns the matcher for a single matcher phrase
+func (r *Registry) leaf(text string) (types.GomegaMatcher, error) {
+	for _, d := range r.ordered() {
+		if fields := d.regexp.FindStringSubmatch(text); fields != nil {
+			return d.fn(fields[1:]...)
+		}
+	}
+	return nil, fmt.Errorf("unrecognised matcher %q", text)
+}
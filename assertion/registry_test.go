@@ -0,0 +1,96 @@
+package assertion
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
+)
+
+func TestRegistryRegister(t *testing.T) {
+	beEmpty := func(args ...string) (types.GomegaMatcher, error) { return BeEmpty(), nil }
+
+	tests := []struct {
+		usage   string
+		pattern string
+		fn      MatcherFunc
+		err     string
+	}{
+		{usage: "be empty", pattern: `be empty`, fn: beEmpty},
+		{usage: "be blank", pattern: `be (blank)`, fn: beEmpty},
+		{usage: "", pattern: `be void`, fn: beEmpty, err: `matcher "be void" must have a usage`},
+		{usage: "be void", pattern: `be void`, err: `matcher "be void" must have a MatcherFunc`},
+		{usage: "be void", pattern: `be (void`, fn: beEmpty, err: `matcher "be void" has an invalid pattern`},
+		{usage: "equal <value>", pattern: `equals (.*)`, fn: beEmpty, err: `matcher "equal <value>" is already registered`},
+		{usage: "equals <value>", pattern: `equal (.*)`, fn: beEmpty, err: `matcher "equals <value>" conflicts with "equal <value>", both use the pattern "equal (.*)"`},
+		{usage: "equal to <value>", pattern: `equal to (.*)`, fn: beEmpty},
+		{usage: "equal to the <value>", pattern: `equal to the (.*)`, fn: beEmpty, err: `matcher "equal to the <value>" would never be used, "equal to <value>" is tried first and matches "equal to the 1"`},
+		{usage: "contain exactly <n> items", pattern: `contain exactly (\d+) items`, fn: beEmpty},
+		{usage: "be empty or blank", pattern: `be empty or blank`, fn: beEmpty},
+	}
+
+	r := NewRegistry()
+	RegisterBuiltins(r)
+	for _, tt := range tests {
+		t.Run(tt.usage, func(t *testing.T) {
+			err := r.Register(tt.usage, tt.pattern, tt.fn)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+				t.Errorf("expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestRegistryOrder(t *testing.T) {
+	r := NewRegistry()
+	RegisterBuiltins(r)
+	r.MustRegister("be empty", `be empty`, func(args ...string) (types.GomegaMatcher, error) { return BeEmpty(), nil })
+
+	r.MustRegister("contain exactly <n> items", `contain exactly (\d+) items`, func(args ...string) (types.GomegaMatcher, error) {
+		n, _ := strconv.Atoi(args[0])
+		return HaveLen(n), nil
+	})
+
+	definitions := r.Matchers()
+	if definitions[0].Usage != "be empty" || definitions[1].Usage != "contain exactly <n> items" || definitions[2].Usage != "equal <quantity>" {
+		t.Errorf("expected the registered matchers to be tried before the built in matchers, got %v", definitions)
+	}
+
+	m, err := r.GetMatcher("contain exactly 2 items")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if match, _ := m.Match([]string{"a", "b"}); !match {
+		t.Error("expected a registered matcher to be used before a built in matcher it extends")
+	}
+	m, err = r.GetMatcher("contain exactly two")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if match, _ := m.Match("contain exactly two"); !match {
+		t.Error("expected the built in matcher to be used when the registered matcher does not match")
+	}
+
+	m, err = r.GetMatcher("be empty and not equal x")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if match, _ := m.Match(""); !match {
+		t.Error("expected a registered matcher to combine with the built in matchers")
+	}
+}
+
+func TestRegistryIsolation(t *testing.T) {
+	r := NewRegistry()
+	if _, err := r.GetMatcher("equal 1"); err == nil {
+		t.Error("expected an empty registry not to know the built in matchers")
+	}
+}
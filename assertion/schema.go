@@ -0,0 +1,82 @@
+package assertion
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/onsi/gomega/types"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	"k8s.io/kube-openapi/pkg/validation/strfmt"
+	"k8s.io/kube-openapi/pkg/validation/validate"
+	"sigs.k8s.io/yaml"
+)
+
+// ParseSchema parses a JSON Schema from a JSON or YAML document.
+func ParseSchema(data []byte) (*spec.Schema, error) {
+	schemaJSON, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	schema := &spec.Schema{}
+	if err := schema.UnmarshalJSON(schemaJSON); err != nil {
+		return nil, fmt.Errorf("invalid JSON Schema: %w", err)
+	}
+	return schema, nil
+}
+
+// ConformToSchema succeeds if actual is valid against the JSON Schema. A
+// string is decoded as a JSON or YAML document first unless the schema
+// expects a string, so embedded documents such as ConfigMap data can be
+// validated.
+func ConformToSchema(schema *spec.Schema) *SchemaMatcher {
+	return &SchemaMatcher{
+		Schema: schema,
+	}
+}
+
+type SchemaMatcher struct {
+	Schema *spec.Schema
+
+	violations []string
+}
+
+func (m *SchemaMatcher) Match(actual interface{}) (bool, error) {
+	m.violations = nil
+	if s, ok := actual.(string); ok && !m.Schema.Type.Contains("string") {
+		var document interface{}
+		if err := yaml.Unmarshal([]byte(s), &document); err != nil {
+			return false, fmt.Errorf("SchemaMatcher could not decode the string as a JSON or YAML document: %w", err)
+		}
+		actual = document
+	}
+	if u, ok := actual.(unstructuredContent); ok {
+		actual = u.UnstructuredContent()
+	}
+
+	result := validate.NewSchemaValidator(m.Schema, nil, "$", strfmt.Default).Validate(actual)
+	for _, err := range result.Errors {
+		m.violations = append(m.violations, err.Error())
+	}
+	return result.IsValid(), nil
+}
+
+func (m *SchemaMatcher) FailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected value to conform to the schema but found %d violation(s):\n  %s", len(m.violations), strings.Join(m.violations, "\n  "))
+}
+
+func (m *SchemaMatcher) NegatedFailureMessage(actual interface{}) string {
+	return "Expected value not to conform to the schema"
+}
+
+func conformToSchemaFile(args ...string) (types.GomegaMatcher, error) {
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return nil, err
+	}
+	schema, err := ParseSchema(data)
+	if err != nil {
+		return nil, err
+	}
+	return ConformToSchema(schema), nil
+}
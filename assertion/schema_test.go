@@ -0,0 +1,106 @@
+package assertion
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSchema = `
+type: object
+required: [name]
+properties:
+  name:
+    type: string
+  replicas:
+    type: integer
+    minimum: 1
+`
+
+func TestConformToSchema(t *testing.T) {
+	schema, err := ParseSchema([]byte(testSchema))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		name      string
+		actual    interface{}
+		match     bool
+		violation string
+	}{
+		{name: "valid object", actual: map[string]interface{}{"name": "web", "replicas": 2}, match: true},
+		{name: "valid document", actual: "name: web\nreplicas: 2", match: true},
+		{name: "valid JSON document", actual: `{"name": "web"}`, match: true},
+		{name: "missing field", actual: map[string]interface{}{"replicas": 2}, violation: "$.name in body is required"},
+		{name: "wrong type", actual: "name: 1", violation: "$.name in body must be of type string"},
+		{name: "out of range", actual: "name: web\nreplicas: 0", violation: "$.replicas in body should be greater than or equal to 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := ConformToSchema(schema)
+			match, err := m.Match(tt.actual)
+			if err != nil {
+				t.Fatalf("unexpected match error: %s", err)
+			}
+			if match != tt.match {
+				t.Fatalf("expected match %t for %v, got %t", tt.match, tt.actual, match)
+			}
+			if msg := m.FailureMessage(tt.actual); !strings.Contains(msg, tt.violation) {
+				t.Errorf("expected the failure message to contain %q, got %q", tt.violation, msg)
+			}
+		})
+	}
+}
+
+func TestConformToSchemaUndecodableString(t *testing.T) {
+	schema, err := ParseSchema([]byte(testSchema))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := ConformToSchema(schema).Match("name: [web"); err == nil {
+		t.Error("expected an error for a string which is not a JSON or YAML document")
+	}
+}
+
+func TestParseSchemaError(t *testing.T) {
+	if _, err := ParseSchema([]byte("type: [object")); err == nil {
+		t.Error("expected an error for a schema which is not YAML")
+	}
+	if _, err := ParseSchema([]byte("required: name")); err == nil || !strings.HasPrefix(err.Error(), "invalid JSON Schema") {
+		t.Errorf("expected an invalid JSON Schema error, got %v", err)
+	}
+}
+
+func TestConformToSchemaFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "schema.yaml")
+	if err := os.WriteFile(path, []byte(testSchema), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := GetMatcher("conform to schema file " + path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if match, err := m.Match("name: web"); err != nil || !match {
+		t.Errorf("expected the document to conform to the schema file, got %t, %v", match, err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "missing file", path: filepath.Join(dir, "missing.yaml")},
+		{name: "unreadable file", path: dir},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := GetMatcher("conform to schema file " + tt.path); err == nil {
+				t.Errorf("expected an error for %s", tt.path)
+			}
+		})
+	}
+}
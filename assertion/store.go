@@ -0,0 +1,41 @@
+package assertion
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/onsi/gomega/types"
+)
+
+var (
+	storeSuffix       = regexp.MustCompile(`^(.*) and store group (\d+) as ([_[:alpha:]][_[:alpha:][:digit:]]*)$`)
+	matcherRegexStore = regexp.MustCompile(`^match regex (.*)$`)
+)
+
+// Captures holds the values stored by matchers, keyed by variable name
+type Captures map[string]string
+
+// storeMatcher matches a regex and stores one of its groups when it succeeds
+type storeMatcher struct {
+	types.GomegaMatcher
+	regexp   *regexp.Regexp
+	group    int
+	name     string
+	captures Captures
+}
+
+func (m *storeMatcher) Match(actual interface{}) (bool, error) {
+	success, err := m.GomegaMatcher.Match(actual)
+	if err != nil || !success {
+		return success, err
+	}
+
+	s, ok := actual.(string)
+	if !ok {
+		s = fmt.Sprint(actual)
+	}
+	if groups := m.regexp.FindStringSubmatch(s); groups != nil {
+		m.captures[m.name] = groups[m.group]
+	}
+	return true, nil
+}
@@ -0,0 +1,89 @@
+package assertion
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGetMatcherWithCaptures(t *testing.T) {
+	tests := []struct {
+		text     string
+		actual   interface{}
+		match    bool
+		captures Captures
+	}{
+		{`match regex :v(\d+) and store group 1 as version`, "nginx:v12", true, Captures{"version": "12"}},
+		{`match regex :v(\d+) and store group 0 as tag`, "nginx:v12", true, Captures{"tag": ":v12"}},
+		{`match regex :v(\d+) and store group 1 as version`, "nginx:latest", false, Captures{}},
+		{`match regex "^(\w+) and" and store group 1 as first`, "rock and roll", true, Captures{"first": "rock"}},
+		{`have prefix nginx and match regex :v(\d+) and store group 1 as version`, "nginx:v3", true, Captures{"version": "3"}},
+		{`(match regex (\d+) and store group 1 as port)`, "8080", true, Captures{"port": "8080"}},
+		{`match regex ^(\w+) and store group 1 as first and match regex (\w+)$ and store group 1 as last`, "a b c", true, Captures{"first": "a", "last": "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			m, captures, err := GetMatcherWithCaptures(tt.text)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			match, err := m.Match(tt.actual)
+			if err != nil {
+				t.Fatalf("unexpected match error: %s", err)
+			}
+			if match != tt.match {
+				t.Errorf("expected match %t for %v, got %t", tt.match, tt.actual, match)
+			}
+			if len(captures) != len(tt.captures) {
+				t.Errorf("expected captures %v, got %v", tt.captures, captures)
+			}
+			for name, value := range tt.captures {
+				if captures[name] != value {
+					t.Errorf("expected %s to be stored as %q, got %q", name, value, captures[name])
+				}
+			}
+		})
+	}
+}
+
+func TestGetMatcherWithCapturesSyntaxError(t *testing.T) {
+	const notAllowed = "values can only be stored by the matchers of a step assertion which must all succeed, not under not or or"
+
+	tests := []struct {
+		text string
+		pos  int
+		msg  string
+	}{
+		{`not match regex (\d+) and store group 1 as n`, 22, notAllowed},
+		{`not (match regex (\d+) and store group 1 as n)`, 23, notAllowed},
+		{`equal x or match regex (\d+) and store group 1 as n`, 29, notAllowed},
+		{`match regex (\d+) and store group 1 as n or equal x`, 18, notAllowed},
+		{`equal x and (equal y or match regex (\d+) and store group 1 as n)`, 42, notAllowed},
+		{`have an element (match regex (\d+) and store group 1 as n)`, 35, notAllowed},
+		{`equal x and store group 1 as n`, 0, `only match regex can store a group, not "equal x"`},
+		{`match regex (\d+) and store group 2 as n`, 34, `match regex (\d+) has no group 2`},
+		{`match regex (\d+ and store group 1 as n`, 0, "error parsing regexp: missing closing ): `(\\d+`"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			_, _, err := GetMatcherWithCaptures(tt.text)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("expected a *SyntaxError, got %v", err)
+			}
+			if syntaxErr.Pos != tt.pos {
+				t.Errorf("expected position %d, got %d: %s", tt.pos, syntaxErr.Pos, err)
+			}
+			if syntaxErr.Msg != tt.msg {
+				t.Errorf("expected message %q, got %q", tt.msg, syntaxErr.Msg)
+			}
+		})
+	}
+}
+
+func TestGetMatcherStoreWithoutCaptures(t *testing.T) {
+	if _, err := GetMatcher(`match regex (\d+) and store group 1 as n`); err == nil {
+		t.Error("expected storing to be rejected without captures")
+	}
+}
@@ -0,0 +1,196 @@
+package assertion
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/onsi/gomega/format"
+	"sigs.k8s.io/yaml"
+)
+
+// MatchSubset succeeds if every field in expected is present in actual with
+// the same value. Maps are matched recursively and each element of an
+// expected list must match a different element of the actual list, in any
+// order. actual may be a map or anything with UnstructuredContent, such as
+// an *unstructured.Unstructured.
+func MatchSubset(expected map[string]interface{}) *SubsetMatcher {
+	return &SubsetMatcher{
+		Expected: expected,
+	}
+}
+
+type SubsetMatcher struct {
+	Expected map[string]interface{}
+
+	differences []string
+}
+
+type unstructuredContent interface {
+	UnstructuredContent() map[string]interface{}
+}
+
+func (m *SubsetMatcher) Match(actual interface{}) (bool, error) {
+	var content map[string]interface{}
+	switch v := actual.(type) {
+	case map[string]interface{}:
+		content = v
+	case unstructuredContent:
+		content = v.UnstructuredContent()
+	default:
+		return false, fmt.Errorf("SubsetMatcher expects a map or an unstructured object. Got:\n%s", format.Object(actual, 1))
+	}
+
+	m.differences = subsetDiff("", m.Expected, content)
+	return len(m.differences) == 0, nil
+}
+
+func (m *SubsetMatcher) FailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected object to match:\n%s\nDifferences:\n  %s", indentYAML(m.Expected), strings.Join(m.differences, "\n  "))
+}
+
+func (m *SubsetMatcher) NegatedFailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected object not to match:\n%s", indentYAML(m.Expected))
+}
+
+// subsetDiff returns a line for every field of expected which differs from
+// actual, prefixed with the path of the field.
+func subsetDiff(path string, expected, actual interface{}) []string {
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected a map, got %s", displayPath(path), display(actual))}
+		}
+		keys := make([]string, 0, len(e))
+		for key := range e {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var diffs []string
+		for _, key := range keys {
+			value, ok := a[key]
+			if !ok {
+				diffs = append(diffs, fmt.Sprintf("%s.%s: missing, expected %s", path, key, display(e[key])))
+				continue
+			}
+			diffs = append(diffs, subsetDiff(path+"."+key, e[key], value)...)
+		}
+		return diffs
+
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected a list, got %s", displayPath(path), display(actual))}
+		}
+		var diffs []string
+		candidates := make([][]int, len(e))
+		for i := range e {
+			for j := range a {
+				if len(subsetDiff("", e[i], a[j])) == 0 {
+					candidates[i] = append(candidates[i], j)
+				}
+			}
+		}
+		for i, matched := range matchElements(candidates, len(a)) {
+			switch {
+			case matched:
+			case len(candidates[i]) == 0:
+				diffs = append(diffs, fmt.Sprintf("%s[%d]: no element matches %s", displayPath(path), i, display(e[i])))
+			default:
+				diffs = append(diffs, fmt.Sprintf("%s[%d]: every element matching %s also matches another expected element", displayPath(path), i, display(e[i])))
+			}
+		}
+		return diffs
+
+	default:
+		if !scalarEqual(expected, actual) {
+			return []string{fmt.Sprintf("%s: expected %s, got %s", displayPath(path), display(expected), display(actual))}
+		}
+		return nil
+	}
+}
+
+// matchElements pairs each expected element with a different actual element
+// from its candidates, finding a maximum matching with augmenting paths so
+// an earlier element never takes the only candidate of a later one. It
+// returns whether each expected element was paired.
+func matchElements(candidates [][]int, actualLen int) []bool {
+	owner := make([]int, actualLen)
+	for j := range owner {
+		owner[j] = -1
+	}
+
+	var assign func(i int, visited []bool) bool
+	assign = func(i int, visited []bool) bool {
+		for _, j := range candidates[i] {
+			if visited[j] {
+				continue
+			}
+			visited[j] = true
+			if owner[j] < 0 || assign(owner[j], visited) {
+				owner[j] = i
+				return true
+			}
+		}
+		return false
+	}
+
+	matched := make([]bool, len(candidates))
+	for i := range candidates {
+		matched[i] = assign(i, make([]bool, actualLen))
+	}
+	return matched
+}
+
+// scalarEqual compares values ignoring the differences in numeric types
+// between YAML documents and unstructured objects.
+func scalarEqual(expected, actual interface{}) bool {
+	if e, ok := toFloat(expected); ok {
+		a, ok := toFloat(actual)
+		return ok && e == a
+	}
+	return reflect.DeepEqual(expected, actual)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "."
+	}
+	return path
+}
+
+func display(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+func indentYAML(v interface{}) string {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("  %v", v)
+	}
+	return "  " + strings.ReplaceAll(strings.TrimSpace(string(b)), "\n", "\n  ")
+}
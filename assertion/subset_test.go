@@ -0,0 +1,111 @@
+package assertion
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func TestMatchSubset(t *testing.T) {
+	tests := []struct {
+		name        string
+		expected    string
+		actual      string
+		differences []string
+	}{
+		{
+			name:     "fields",
+			expected: "metadata: {name: web}\nspec: {replicas: 3}",
+			actual:   "metadata: {name: web, namespace: default}\nspec: {replicas: 3, paused: false}",
+		},
+		{
+			name:        "different value",
+			expected:    "spec: {replicas: 3}",
+			actual:      "spec: {replicas: 2}",
+			differences: []string{".spec.replicas: expected 3, got 2"},
+		},
+		{
+			name:        "missing field",
+			expected:    "spec: {replicas: 3}",
+			actual:      "spec: {}",
+			differences: []string{".spec.replicas: missing, expected 3"},
+		},
+		{
+			name:        "not a map",
+			expected:    "spec: {replicas: 3}",
+			actual:      "spec: [1]",
+			differences: []string{".spec: expected a map, got [1]"},
+		},
+		{
+			name:     "numbers of different types",
+			expected: "spec: {replicas: 3.0}",
+			actual:   "spec: {replicas: 3}",
+		},
+		{
+			name:     "list in any order",
+			expected: "items: [b, a]",
+			actual:   "items: [a, b, c]",
+		},
+		{
+			name:     "list needing backtracking",
+			expected: "items: [{a: 1}, {a: 1, b: 2}]",
+			actual:   "items: [{a: 1, b: 2}, {a: 1}]",
+		},
+		{
+			name:     "list where the first candidate is needed later",
+			expected: "items: [{x: 1}, {x: 1, y: 1}, {x: 1, y: 1, z: 1}]",
+			actual:   "items: [{x: 1, y: 1, z: 1}, {x: 1, y: 1}, {x: 1}]",
+		},
+		{
+			name:        "list element with no match",
+			expected:    "items: [a, d]",
+			actual:      "items: [a, b, c]",
+			differences: []string{".items[1]: no element matches \"d\""},
+		},
+		{
+			name:        "list element matched twice",
+			expected:    "items: [{a: 1}, {a: 1}]",
+			actual:      "items: [{a: 1, b: 2}]",
+			differences: []string{".items[1]: every element matching {\"a\":1} also matches another expected element"},
+		},
+		{
+			name:        "not a list",
+			expected:    "items: [a]",
+			actual:      "items: a",
+			differences: []string{".items: expected a list, got \"a\""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected := map[string]interface{}{}
+			if err := yaml.Unmarshal([]byte(tt.expected), &expected); err != nil {
+				t.Fatal(err)
+			}
+			actual := &unstructured.Unstructured{}
+			if err := yaml.Unmarshal([]byte(tt.actual), &actual.Object); err != nil {
+				t.Fatal(err)
+			}
+
+			m := MatchSubset(expected)
+			match, err := m.Match(actual)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if match != (len(tt.differences) == 0) {
+				t.Errorf("expected match %t, got %t", len(tt.differences) == 0, match)
+			}
+			if !reflect.DeepEqual(m.differences, tt.differences) {
+				t.Errorf("expected differences %q, got %q", tt.differences, m.differences)
+			}
+		})
+	}
+}
+
+func TestMatchSubsetRejectsNonObjects(t *testing.T) {
+	if _, err := MatchSubset(map[string]interface{}{}).Match("web"); err == nil {
+		t.Error("expected an error matching a string")
+	}
+}
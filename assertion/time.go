@@ -0,0 +1,148 @@
+package assertion
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/onsi/gomega/format"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BeOlderThan succeeds if actual is a timestamp more than d in the past.
+func BeOlderThan(d time.Duration) *TimeMatcher {
+	return &TimeMatcher{Relation: "older than", Duration: d}
+}
+
+// BeNewerThan succeeds if actual is a timestamp less than d in the past.
+func BeNewerThan(d time.Duration) *TimeMatcher {
+	return &TimeMatcher{Relation: "newer than", Duration: d}
+}
+
+// BeBefore succeeds if actual is a timestamp before t. A zero t is now.
+func BeBefore(t time.Time) *TimeMatcher {
+	return &TimeMatcher{Relation: "before", Time: t}
+}
+
+// BeAfter succeeds if actual is a timestamp after t. A zero t is now.
+func BeAfter(t time.Time) *TimeMatcher {
+	return &TimeMatcher{Relation: "after", Time: t}
+}
+
+// BeWithin succeeds if actual is a timestamp no more than d either side of
+// t. A zero t is now.
+func BeWithin(d time.Duration, t time.Time) *TimeMatcher {
+	return &TimeMatcher{Relation: "within", Duration: d, Time: t}
+}
+
+type TimeMatcher struct {
+	Relation string
+	Duration time.Duration
+	// Time is the reference time, the zero value is the time of matching
+	Time time.Time
+
+	now time.Time
+}
+
+func (m *TimeMatcher) Match(actual interface{}) (bool, error) {
+	t, err := toTime(actual)
+	if err != nil {
+		return false, err
+	}
+
+	m.now = time.Now()
+	ref := m.Time
+	if ref.IsZero() {
+		ref = m.now
+	}
+
+	switch m.Relation {
+	case "older than":
+		return m.now.Sub(t) > m.Duration, nil
+	case "newer than":
+		return m.now.Sub(t) < m.Duration, nil
+	case "before":
+		return t.Before(ref), nil
+	case "after":
+		return t.After(ref), nil
+	case "within":
+		diff := t.Sub(ref)
+		if diff < 0 {
+			diff = -diff
+		}
+		return diff <= m.Duration, nil
+	default:
+		return false, fmt.Errorf("unknown time relation %q", m.Relation)
+	}
+}
+
+func (m *TimeMatcher) FailureMessage(actual interface{}) string {
+	return m.message(actual, "to be")
+}
+
+func (m *TimeMatcher) NegatedFailureMessage(actual interface{}) string {
+	return m.message(actual, "not to be")
+}
+
+func (m *TimeMatcher) message(actual interface{}, to string) string {
+	expected := m.Duration.String()
+	switch m.Relation {
+	case "before", "after":
+		expected = m.reference()
+	case "within":
+		expected = fmt.Sprintf("%s of %s", m.Duration, m.reference())
+	}
+
+	age := "unknown"
+	if t, err := toTime(actual); err == nil {
+		age = m.now.Sub(t).Round(time.Second).String()
+	}
+
+	return fmt.Sprintf("Expected\n%s\nwith an age of %s\n%s %s\n%s", format.Object(actual, 1), age, to, m.Relation, format.Object(expected, 1))
+}
+
+func (m *TimeMatcher) reference() string {
+	if m.Time.IsZero() {
+		return "now"
+	}
+	return m.Time.Format(time.RFC3339Nano)
+}
+
+// parseTime parses an expected timestamp, "now" parses as the zero time
+func parseTime(text string) (time.Time, error) {
+	text = strings.Trim(text, `"'`)
+	if text == "now" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, text)
+}
+
+// toTime converts a value returned by a JSONPath into a time.Time
+func toTime(actual interface{}) (time.Time, error) {
+	switch v := actual.(type) {
+	case time.Time:
+		return v, nil
+	case metav1.Time:
+		return v.Time, nil
+	case *metav1.Time:
+		if v == nil {
+			return time.Time{}, fmt.Errorf("TimeMatcher expects a timestamp, got nil")
+		}
+		return v.Time, nil
+	case metav1.MicroTime:
+		return v.Time, nil
+	case *metav1.MicroTime:
+		if v == nil {
+			return time.Time{}, fmt.Errorf("TimeMatcher expects a timestamp, got nil")
+		}
+		return v.Time, nil
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("TimeMatcher expects an RFC3339 timestamp: %w", err)
+		}
+		return t, nil
+	default:
+		return time.Time{}, fmt.Errorf("TimeMatcher expects a timestamp. Got:\n%s", format.Object(actual, 1))
+	}
+}
@@ -0,0 +1,77 @@
+package assertion
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTimeMatchers(t *testing.T) {
+	hourAgo := time.Now().Add(-time.Hour)
+	hourAgoText := hourAgo.UTC().Format(time.RFC3339)
+	var nilTime *metav1.Time
+
+	tests := []struct {
+		text     string
+		actual   interface{}
+		match    bool
+		matchErr bool
+	}{
+		{"be older than 30m", hourAgoText, true, false},
+		{"be older than 2h", hourAgoText, false, false},
+		{"be newer than 2h", hourAgoText, true, false},
+		{"be newer than 30m", hourAgoText, false, false},
+		{"be older than 30m", metav1.NewTime(hourAgo), true, false},
+		{"be older than 30m", &metav1.Time{Time: hourAgo}, true, false},
+		{"be newer than 1m", metav1.NewMicroTime(time.Now()), true, false},
+		{"be before now", hourAgoText, true, false},
+		{"be after now", hourAgoText, false, false},
+		{"be after now", time.Now().Add(time.Hour), true, false},
+		{"be before 2023-01-01T00:00:00Z", "2022-12-31T23:59:59Z", true, false},
+		{"be before 2023-01-01T00:00:00Z", "2023-01-01T00:00:00Z", false, false},
+		{"be after 2023-01-01T00:00:00Z", "2023-01-01T00:00:00.000000001Z", true, false},
+		{`be before "2023-01-01T00:00:00Z"`, "2022-12-31T23:59:59Z", true, false},
+		{"be within 0s of 2023-01-01T00:00:00Z", "2023-01-01T01:00:00+01:00", true, false},
+		{"be within 5s of 2023-01-01T00:00:00Z", "2023-01-01T00:00:05Z", true, false},
+		{"be within 5s of 2023-01-01T00:00:00Z", "2022-12-31T23:59:54Z", false, false},
+		{"be within 1m of now", time.Now().UTC().Format(time.RFC3339), true, false},
+		{"be older than 1s", "yesterday", false, true},
+		{"be older than 1s", 1672531200, false, true},
+		{"be older than 1s", nilTime, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			m, err := GetMatcher(tt.text)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			match, err := m.Match(tt.actual)
+			if tt.matchErr {
+				if err == nil {
+					t.Fatalf("expected an error matching %v", tt.actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected match error: %s", err)
+			}
+			if match != tt.match {
+				t.Errorf("expected match %t for %v, got %t", tt.match, tt.actual, match)
+			}
+		})
+	}
+}
+
+func TestTimeMatcherInvalidExpectation(t *testing.T) {
+	for _, text := range []string{
+		"be older than an hour",
+		"be before tomorrow",
+		"be within 5s of 2023-01-01",
+	} {
+		if _, err := GetMatcher(text); err == nil {
+			t.Errorf("expected an error parsing %q", text)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package assertion
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/onsi/gomega/types"
+)
+
+const maxObservationLength = 60
+
+// Timeline records each distinct value observed by a matcher while polling,
+// so a failure can show how the value changed, e.g.
+//
+//	Pending (0s) → ContainerCreating (4s) → CrashLoopBackOff (31s)
+type Timeline struct {
+	lock         sync.Mutex
+	start        time.Time
+	observations []observation
+}
+
+type observation struct {
+	value   string
+	elapsed time.Duration
+}
+
+// NewTimeline returns an empty Timeline.
+func NewTimeline() *Timeline {
+	return &Timeline{}
+}
+
+// Record returns a matcher which records each value passed to m.
+func (t *Timeline) Record(m types.GomegaMatcher) types.GomegaMatcher {
+	return &recordingMatcher{GomegaMatcher: m, timeline: t}
+}
+
+// Report returns a matcher which appends the timeline to the failure
+// messages of m.
+func (t *Timeline) Report(m types.GomegaMatcher) types.GomegaMatcher {
+	return &reportingMatcher{GomegaMatcher: m, timeline: t}
+}
+
+func (t *Timeline) observe(actual interface{}) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := time.Now()
+	if t.start.IsZero() {
+		t.start = now
+	}
+
+	value := compact(actual)
+	if n := len(t.observations); n > 0 && t.observations[n-1].value == value {
+		return
+	}
+	t.observations = append(t.observations, observation{value: value, elapsed: now.Sub(t.start)})
+}
+
+// String returns the observed values and when they were first seen
+func (t *Timeline) String() string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	values := make([]string, len(t.observations))
+	for i, o := range t.observations {
+		values[i] = fmt.Sprintf("%s (%s)", o.value, o.elapsed.Round(time.Second))
+	}
+	return strings.Join(values, " → ")
+}
+
+func (t *Timeline) message(message string) string {
+	if s := t.String(); s != "" {
+		return fmt.Sprintf("%s\nObserved: %s", message, s)
+	}
+	return message
+}
+
+func compact(actual interface{}) string {
+	var s string
+	switch v := actual.(type) {
+	case string:
+		s = v
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			s = fmt.Sprintf("%v", v)
+		} else {
+			s = string(b)
+		}
+	}
+	if s == "" {
+		s = `""`
+	}
+	if runes := []rune(s); len(runes) > maxObservationLength {
+		s = string(runes[:maxObservationLength-3]) + "..."
+	}
+	return s
+}
+
+type recordingMatcher struct {
+	types.GomegaMatcher
+	timeline *Timeline
+}
+
+func (m *recordingMatcher) Match(actual interface{}) (bool, error) {
+	m.timeline.observe(actual)
+	return m.GomegaMatcher.Match(actual)
+}
+
+type reportingMatcher struct {
+	types.GomegaMatcher
+	timeline *Timeline
+}
+
+func (m *reportingMatcher) FailureMessage(actual interface{}) string {
+	return m.timeline.message(m.GomegaMatcher.FailureMessage(actual))
+}
+
+func (m *reportingMatcher) NegatedFailureMessage(actual interface{}) string {
+	return m.timeline.message(m.GomegaMatcher.NegatedFailureMessage(actual))
+}
@@ -0,0 +1,66 @@
+package assertion
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestTimeline(t *testing.T) {
+	tests := []struct {
+		name     string
+		observed []interface{}
+		expected string
+	}{
+		{"nothing observed", nil, ""},
+		{"repeated value", []interface{}{"Pending", "Pending"}, "Pending (0s)"},
+		{"changing values", []interface{}{"Pending", "Running", "Running", "Pending"}, "Pending (0s) → Running (0s) → Pending (0s)"},
+		{"empty string", []interface{}{""}, `"" (0s)`},
+		{"non strings", []interface{}{3, map[string]interface{}{"ready": true}, nil}, `3 (0s) → {"ready":true} (0s) → null (0s)`},
+		{"long value", []interface{}{strings.Repeat("a", 100)}, strings.Repeat("a", 57) + "... (0s)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeline := NewTimeline()
+			m := timeline.Record(Not(BeNil()))
+			for _, o := range tt.observed {
+				m.Match(o)
+			}
+			if s := timeline.String(); s != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, s)
+			}
+		})
+	}
+}
+
+func TestTimelineTruncatesRunes(t *testing.T) {
+	s := compact(strings.Repeat("→", 100))
+	if !utf8.ValidString(s) || utf8.RuneCountInString(s) != maxObservationLength {
+		t.Errorf("expected %d valid runes, got %q", maxObservationLength, s)
+	}
+}
+
+func TestTimelineReport(t *testing.T) {
+	timeline := NewTimeline()
+	m := timeline.Report(timeline.Record(Equal("Running")))
+
+	if msg := m.FailureMessage("Pending"); strings.Contains(msg, "Observed:") {
+		t.Errorf("expected no observations before matching, got %q", msg)
+	}
+
+	for _, phase := range []string{"Pending", "ContainerCreating"} {
+		if match, _ := m.Match(phase); match {
+			t.Fatalf("expected %s not to match", phase)
+		}
+	}
+	msg := m.FailureMessage("ContainerCreating")
+	if !strings.HasSuffix(msg, "\nObserved: Pending (0s) → ContainerCreating (0s)") {
+		t.Errorf("expected the observations after the failure message, got %q", msg)
+	}
+	if msg := m.NegatedFailureMessage("ContainerCreating"); !strings.Contains(msg, "Observed:") {
+		t.Errorf("expected the observations in the negated failure message, got %q", msg)
+	}
+}
@@ -0,0 +1,95 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func (k *kubernetesScenario) iApplyAResource(ctx context.Context, ref, manager, force string) (err error) {
+	defer failHandler(&err)
+
+	if objs, ok := k.resourceSet(ref); ok {
+		for _, u := range objs {
+			k.apply(ctx, u, manager, force != "")
+		}
+		return nil
+	}
+
+	u := k.lookup(ref)
+	k.apply(ctx, u, manager, force != "")
+
+	return nil
+}
+
+// apply server side applies the registered object. Objects created by the
+// apply are cleaned up like those created by I create.
+func (k *kubernetesScenario) apply(ctx context.Context, u *unstructured.Unstructured, manager string, force bool) {
+	opts := []client.PatchOption{client.FieldOwner(manager)}
+	if force {
+		opts = append(opts, client.ForceOwnership)
+	}
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(u.GroupVersionKind())
+	existing.SetName(u.GetName())
+	existing.SetNamespace(u.GetNamespace())
+	err := k.Get(ctx, existing)
+	Expect(client.IgnoreNotFound(err)).ShouldNot(HaveOccurred())
+	created := apierrors.IsNotFound(err)
+
+	obj := applyConfiguration(u)
+	Eventually(func() error {
+		err := k.Patch(ctx, obj, client.Apply, opts...)
+		if msg, ok := applyConflictMessage(err); ok {
+			return StopTrying(fmt.Sprintf("Apply of %s %s as manager %s conflicts with other field managers:\n%s",
+				u.GetKind(), u.GetName(), manager, msg))
+		}
+		return err
+	}).WithContext(ctx).Should(Succeed())
+
+	if created {
+		k.client.Track(obj)
+	}
+}
+
+// applyConfiguration returns a copy of an object without the fields set by
+// the server, which may have been filled in by an earlier step
+func applyConfiguration(u *unstructured.Unstructured) *unstructured.Unstructured {
+	obj := u.DeepCopy()
+	obj.SetResourceVersion("")
+	obj.SetUID("")
+	obj.SetGeneration(0)
+	obj.SetCreationTimestamp(metav1.Time{})
+	obj.SetManagedFields(nil)
+	obj.SetSelfLink("")
+	unstructured.RemoveNestedField(obj.Object, "status")
+	return obj
+}
+
+// applyConflictMessage lists the conflicting fields and their managers if
+// err is an apply conflict
+func applyConflictMessage(err error) (string, bool) {
+	var status apierrors.APIStatus
+	if !apierrors.IsConflict(err) || !errors.As(err, &status) || status.Status().Details == nil {
+		return "", false
+	}
+
+	var conflicts []string
+	for _, cause := range status.Status().Details.Causes {
+		if cause.Type == metav1.CauseTypeFieldManagerConflict {
+			conflicts = append(conflicts, fmt.Sprintf("  %s: %s", cause.Field, cause.Message))
+		}
+	}
+	if len(conflicts) == 0 {
+		return "", false
+	}
+	return strings.Join(conflicts, "\n") + "\nApply with force to take ownership of these fields.", true
+}
@@ -3,6 +3,7 @@ package kubernetes
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/cucumber/godog"
@@ -14,8 +15,14 @@ import (
 	. "github.com/testernetes/gkube"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/yaml"
 )
 
+// eventPhrase matches e.g. a Warning event with reason "BackOff", events of
+// type Warning, or an event with reason "Pulled" and message contain nginx
+const eventPhrase = `(?:an? |any )?(?:(Normal|Warning) )?events?(?: of type (Normal|Warning))?(?: with reason "([^"]*)")?(?: (?:with|and) message (.+))?`
+
 func (k *kubernetesScenario) AddAssertSteps(sc *godog.ScenarioContext) {
 	eventuallyPhrases := []string{
 		"in less than ",
@@ -26,58 +33,230 @@ func (k *kubernetesScenario) AddAssertSteps(sc *godog.ScenarioContext) {
 		"",
 	}
 	for _, phrase := range eventuallyPhrases {
-		sc.Step(fmt.Sprintf(`^%s(\d+\w{1,2})*[,]?\s?([a-z0-9][-a-z0-9]*[a-z0-9])'s '([^']*)' should (.*)$`, phrase), k.eventuallyObjectWithTimeout)
-		sc.Step(fmt.Sprintf(`^%s(\d+\w{1,2})*[,]?\s?([a-z0-9][-a-z0-9]*[a-z0-9])'s '([^']*)' should not (.*)$`, phrase), k.eventuallyNotObjectWithTimeout)
-		sc.Step(fmt.Sprintf(`^%s(\d+\w{1,2})*[,]?\s?([a-z0-9][-a-z0-9]*[a-z0-9])'s exit code should be (\d+)$`, phrase), k.exitCodeShouldBe)
-		sc.Step(fmt.Sprintf(`^%s(\d+\w{1,2})*[,]?\s?([a-z0-9][-a-z0-9]*[a-z0-9]) should log "([^"]*)"$`, phrase), k.shouldSay)
+		prefix := fmt.Sprintf(`^%s(\d+\w{1,2})*[,]?\s?`, phrase)
+		k.addAssertSteps(sc, prefix, eventually)
+		sc.Step(fmt.Sprintf(`%sthe rollout of %s should complete$`, prefix, objectRef), k.rolloutStep(eventually))
+		sc.Step(fmt.Sprintf(`%s%s should not exist$`, prefix, objectRef), k.existenceStep(eventually, true))
+		sc.Step(fmt.Sprintf(`%s%s's exit code should be (\d+)$`, prefix, objectRef), k.exitCodeShouldBe)
+		sc.Step(fmt.Sprintf(`%s%s should log "([^"]*)"$`, prefix, objectRef), k.shouldSay)
 	}
 	consistentlyPhrases := []string{
 		"for at least",
 		"for no less than",
 	}
 	for _, phrase := range consistentlyPhrases {
-		sc.Step(fmt.Sprintf(`^%s (\w*)[,]? ([a-z0-9][-a-z0-9]*[a-z0-9])'s '([^']*)' should (.*)$`, phrase), k.consistentlyObjectWithTimeout)
-		sc.Step(fmt.Sprintf(`^%s (\w*)[,]? ([a-z0-9][-a-z0-9]*[a-z0-9])'s '([^']*)' should not (.*)$`, phrase), k.consistentlyNotObjectWithTimeout)
+		prefix := fmt.Sprintf(`^%s (\w*)[,]? `, phrase)
+		k.addAssertSteps(sc, prefix, consistently)
+		sc.Step(fmt.Sprintf(`%s%s should not exist$`, prefix, objectRef), k.existenceStep(consistently, true))
 	}
 }
 
-func (k *kubernetesScenario) eventuallyObjectWithTimeout(ctx context.Context, timeout, ref, jsonpath, matcherText string) (err error) {
-	defer failHandler(&err)
-	o, matcher, d := k.parseAssertion(ref, jsonpath, matcherText, timeout)
-	Eventually(k.Object).WithContext(ctx).WithArguments(o).WithTimeout(d).Should(matcher)
-	return nil
+// addAssertSteps registers the assertions which can be made both eventually
+// and consistently, after a prefix capturing the timeout
+func (k *kubernetesScenario) addAssertSteps(sc *godog.ScenarioContext, prefix string, run runner) {
+	sc.Step(fmt.Sprintf(`%s(all|any) %s's? '([^']*)' should (.*)$`, prefix, objectRef), k.collectionStep(run))
+	sc.Step(fmt.Sprintf(`%sthere should be (at least |at most )?(\d+) %s$`, prefix, objectRef), k.countStep(run))
+	sc.Step(fmt.Sprintf(`%s%s's '([^']*)' should conform to schema:$`, prefix, objectRef), k.schemaStep(run, false))
+	sc.Step(fmt.Sprintf(`%s%s's '([^']*)' should not conform to schema:$`, prefix, objectRef), k.schemaStep(run, true))
+	sc.Step(fmt.Sprintf(`%s%s's '([^']*)' should (.*)$`, prefix, objectRef), k.jsonPathStep(run, false))
+	sc.Step(fmt.Sprintf(`%s%s's '([^']*)' should not (.*)$`, prefix, objectRef), k.jsonPathStep(run, true))
+	sc.Step(fmt.Sprintf(`%s%s should match:$`, prefix, objectRef), k.matchStep(run, false))
+	sc.Step(fmt.Sprintf(`%s%s should not match:$`, prefix, objectRef), k.matchStep(run, true))
+	sc.Step(fmt.Sprintf(`%s%s should satisfy "(.*)"$`, prefix, objectRef), k.celStep(run, false))
+	sc.Step(fmt.Sprintf(`%s%s should not satisfy "(.*)"$`, prefix, objectRef), k.celStep(run, true))
+	sc.Step(fmt.Sprintf(`%s%s should exist$`, prefix, objectRef), k.existenceStep(run, false))
+	sc.Step(fmt.Sprintf(`%s%s should be (ready|failed)$`, prefix, objectRef), k.statusStep(run))
+	sc.Step(fmt.Sprintf(`%s%s should (not )?have %s$`, prefix, objectRef, eventPhrase), k.eventStep(run))
 }
 
-func (k *kubernetesScenario) eventuallyNotObjectWithTimeout(ctx context.Context, timeout, ref, jsonpath, matcherText string) (err error) {
-	defer failHandler(&err)
-	o, matcher, d := k.parseAssertion(ref, jsonpath, matcherText, timeout)
-	Eventually(k.Object).WithContext(ctx).WithArguments(o).WithTimeout(d).ShouldNot(matcher)
-	return nil
-}
+// poller returns the value an assertion is matched against
+type poller func(ctx context.Context) (interface{}, error)
 
-func (k *kubernetesScenario) consistentlyObjectWithTimeout(ctx context.Context, timeout, ref, jsonpath, matcherText string) (err error) {
-	defer failHandler(&err)
-	o, matcher, d := k.parseAssertion(ref, jsonpath, matcherText, timeout)
-	Consistently(k.Object).WithContext(ctx).WithArguments(o).WithTimeout(d).Should(matcher)
-	return nil
+// runner asserts that polled values satisfy a matcher, or do not when
+// negated, within or for the timeout
+type runner func(ctx context.Context, timeout string, poll poller, matcher types.GomegaMatcher, negate bool)
+
+func eventually(ctx context.Context, timeout string, poll poller, matcher types.GomegaMatcher, negate bool) {
+	assertAsync(Eventually(poll).WithContext(ctx).WithTimeout(parseTimeout(timeout)), matcher, negate)
 }
 
-func (k *kubernetesScenario) consistentlyNotObjectWithTimeout(ctx context.Context, timeout, ref, jsonpath, matcherText string) (err error) {
-	defer failHandler(&err)
-	o, matcher, d := k.parseAssertion(ref, jsonpath, matcherText, timeout)
-	Consistently(k.Object).WithContext(ctx).WithArguments(o).WithTimeout(d).ShouldNot(matcher)
-	return nil
+func consistently(ctx context.Context, timeout string, poll poller, matcher types.GomegaMatcher, negate bool) {
+	assertAsync(Consistently(poll).WithContext(ctx).WithTimeout(parseTimeout(timeout)), matcher, negate)
 }
 
-func (k *kubernetesScenario) exitCodeShouldBe(ctx context.Context, timeout, ref string, code int) (err error) {
-	defer failHandler(&err)
+func assertAsync(a types.AsyncAssertion, matcher types.GomegaMatcher, negate bool) {
+	if negate {
+		a.ShouldNot(matcher)
+		return
+	}
+	a.Should(matcher)
+}
 
+// parseTimeout parses the timeout of an assertion, 1s when none is given
+func parseTimeout(timeout string) time.Duration {
 	if timeout == "" {
 		timeout = "1s"
 	}
 	d, err := time.ParseDuration(timeout)
 	Expect(err).ShouldNot(HaveOccurred())
+	return d
+}
+
+// objectPoller polls the current state of an object
+func (k *kubernetesScenario) objectPoller(u *unstructured.Unstructured) poller {
+	return func(ctx context.Context) (interface{}, error) {
+		return k.Object(ctx, u)
+	}
+}
+
+// jsonPathStep asserts on a JSONPath of an object, or of each object in a
+// collection. Values captured by the matcher are stored as variables.
+func (k *kubernetesScenario) jsonPathStep(run runner, negate bool) func(context.Context, string, string, string, string) (context.Context, error) {
+	return func(ctx context.Context, timeout, ref, jsonpath, matcherText string) (rctx context.Context, err error) {
+		rctx = ctx
+		defer failHandler(&err)
+
+		matcher, captures, err := assertion.GetMatcherWithCaptures(matcherText)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		if c, ok := k.collections[ref]; ok {
+			run(ctx, timeout, c.poller(), c.matcher(quantify("all", c.kind, HaveJSONPath(jsonpath, matcher))), negate)
+			return storeVariables(ctx, captures), nil
+		}
+
+		timeline := assertion.NewTimeline()
+		run(ctx, timeout, k.objectPoller(k.lookup(ref)), timeline.Report(HaveJSONPath(jsonpath, timeline.Record(matcher))), negate)
+		return storeVariables(ctx, captures), nil
+	}
+}
+
+func (k *kubernetesScenario) matchStep(run runner, negate bool) func(context.Context, string, string, *godog.DocString) error {
+	return func(ctx context.Context, timeout, ref string, manifest *godog.DocString) (err error) {
+		defer failHandler(&err)
+
+		Expect(manifest.MediaType).Should(BeElementOf("", "json", "yaml"), "Unrecognised content-type %s. Supported types are json, yaml.", manifest.MediaType)
+		content := strings.ReplaceAll(manifest.Content, "\t", "  ")
+		expected := map[string]interface{}{}
+		Expect(yaml.Unmarshal([]byte(content), &expected)).Should(Succeed())
+
+		run(ctx, timeout, k.objectPoller(k.lookup(ref)), assertion.MatchSubset(expected), negate)
+		return nil
+	}
+}
+
+func (k *kubernetesScenario) celStep(run runner, negate bool) func(context.Context, string, string, string) error {
+	return func(ctx context.Context, timeout, ref, expression string) (err error) {
+		defer failHandler(&err)
+
+		matcher, err := assertion.SatisfyCEL(expression)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		run(ctx, timeout, k.objectPoller(k.lookup(ref)), matcher, negate)
+		return nil
+	}
+}
+
+func (k *kubernetesScenario) schemaStep(run runner, negate bool) func(context.Context, string, string, string, *godog.DocString) error {
+	return func(ctx context.Context, timeout, ref, jsonpath string, schema *godog.DocString) (err error) {
+		defer failHandler(&err)
+
+		Expect(schema.MediaType).Should(BeElementOf("", "json", "yaml"), "Unrecognised content-type %s. Supported types are json, yaml.", schema.MediaType)
+		s, err := assertion.ParseSchema([]byte(strings.ReplaceAll(schema.Content, "\t", "  ")))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		run(ctx, timeout, k.objectPoller(k.lookup(ref)), HaveJSONPath(jsonpath, assertion.ConformToSchema(s)), negate)
+		return nil
+	}
+}
+
+func (k *kubernetesScenario) existenceStep(run runner, negate bool) func(context.Context, string, string) error {
+	return func(ctx context.Context, timeout, ref string) (err error) {
+		defer failHandler(&err)
+
+		u := k.lookup(ref)
+		poll := func(ctx context.Context) (interface{}, error) {
+			return k.existing(ctx, u)
+		}
+		run(ctx, timeout, poll, exist(u), negate)
+		return nil
+	}
+}
+
+func (k *kubernetesScenario) collectionStep(run runner) func(context.Context, string, string, string, string, string) error {
+	return func(ctx context.Context, timeout, quantifier, ref, jsonpath, matcherText string) (err error) {
+		defer failHandler(&err)
+
+		c := k.getCollection(ref)
+		matcher, err := assertion.GetMatcher(matcherText)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		run(ctx, timeout, c.poller(), c.matcher(quantify(quantifier, c.kind, HaveJSONPath(jsonpath, matcher))), false)
+		return nil
+	}
+}
+
+func (k *kubernetesScenario) countStep(run runner) func(context.Context, string, string, int, string) error {
+	return func(ctx context.Context, timeout, bound string, n int, ref string) (err error) {
+		defer failHandler(&err)
+
+		c := k.getCollection(ref)
+		comparator := map[string]string{"": "==", "at least ": ">=", "at most ": "<="}[bound]
+
+		run(ctx, timeout, c.poller(), c.matcher(haveCount(comparator, n, c.kind)), false)
+		return nil
+	}
+}
+
+func (k *kubernetesScenario) statusStep(run runner) func(context.Context, string, string, string) error {
+	return func(ctx context.Context, timeout, ref, state string) (err error) {
+		defer failHandler(&err)
+
+		expected := status.CurrentStatus
+		if state == "failed" {
+			expected = status.FailedStatus
+		}
+
+		run(ctx, timeout, k.objectPoller(k.lookup(ref)), beStatus(expected), false)
+		return nil
+	}
+}
+
+func (k *kubernetesScenario) rolloutStep(run runner) func(context.Context, string, string) error {
+	return func(ctx context.Context, timeout, ref string) (err error) {
+		defer failHandler(&err)
+
+		u := k.lookup(ref)
+		poll := func(ctx context.Context) (interface{}, error) {
+			return k.rolloutStatus(ctx, u)
+		}
+		run(ctx, timeout, poll, completeRollout(u), false)
+		return nil
+	}
+}
+
+func (k *kubernetesScenario) eventStep(run runner) func(context.Context, string, string, string, string, string, string, string) error {
+	return func(ctx context.Context, timeout, ref, not, eventType, ofType, reason, message string) (err error) {
+		defer failHandler(&err)
 
+		var messageMatcher types.GomegaMatcher
+		if message != "" {
+			messageMatcher, err = assertion.GetMatcher(message)
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+
+		u := k.lookup(ref)
+		poll := func(ctx context.Context) (interface{}, error) {
+			return k.events(ctx, u)
+		}
+		run(ctx, timeout, poll, haveEvent(eventType+ofType, reason, messageMatcher), not != "")
+		return nil
+	}
+}
+
+func (k *kubernetesScenario) exitCodeShouldBe(ctx context.Context, timeout, ref string, code int) (err error) {
+	defer failHandler(&err)
+
+	d := parseTimeout(timeout)
 	s, ok := k.podSessions[ref]
 	Expect(ok).Should(BeTrue())
 	Eventually(s).WithTimeout(d).Should(Exit(code))
@@ -88,15 +267,10 @@ func (k *kubernetesScenario) exitCodeShouldBe(ctx context.Context, timeout, ref
 func (k *kubernetesScenario) shouldSay(ctx context.Context, timeout, ref, message string) (err error) {
 	defer failHandler(&err)
 
-	if timeout == "" {
-		timeout = "1s"
-	}
-	d, err := time.ParseDuration(timeout)
-	Expect(err).ShouldNot(HaveOccurred())
-
+	d := parseTimeout(timeout)
 	s, ok := k.podSessions[ref]
 	if !ok {
-		pod := k.getPodFromRegister(ref)
+		pod := k.getPodFromRegister(ctx, ref)
 		Expect(err).ShouldNot(HaveOccurred())
 		Eventually(func() error {
 			s, err = k.Logs(ctx, pod, &corev1.PodLogOptions{
@@ -110,16 +284,3 @@ func (k *kubernetesScenario) shouldSay(ctx context.Context, timeout, ref, messag
 
 	return nil
 }
-
-func (k *kubernetesScenario) parseAssertion(ref, jsonpath, matcherText, timeout string) (*unstructured.Unstructured, types.GomegaMatcher, time.Duration) {
-	u, ok := k.objRegister[ref]
-	Expect(ok).Should(BeTrue(), noResourceErrMsg, ref)
-
-	if timeout == "" {
-		timeout = "1s"
-	}
-	d, err := time.ParseDuration(timeout)
-	Expect(err).ShouldNot(HaveOccurred())
-
-	return u, HaveJSONPath(jsonpath, assertion.GetMatcher(matcherText)), d
-}
@@ -0,0 +1,149 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CleanupPolicy decides whether the objects created by a scenario are
+// deleted when it ends
+type CleanupPolicy string
+
+const (
+	CleanupAlways    CleanupPolicy = "always"
+	CleanupOnSuccess CleanupPolicy = "on-success"
+	CleanupNever     CleanupPolicy = "never"
+)
+
+// CleanupPolicies are the valid cleanup policies. A scenario can override
+// the default with a tag such as @cleanup-never.
+var CleanupPolicies = []CleanupPolicy{CleanupAlways, CleanupOnSuccess, CleanupNever}
+
+// ParseCleanupPolicy returns the cleanup policy with the given name
+func ParseCleanupPolicy(name string) (CleanupPolicy, error) {
+	for _, p := range CleanupPolicies {
+		if string(p) == name {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("unknown cleanup policy %q, expected one of always, on-success or never", name)
+}
+
+// deletionTimeout is how long cleanup waits for each object to be deleted,
+// long enough for a namespace to terminate
+const deletionTimeout = 5 * time.Minute
+
+// cleanupContext returns the context After hooks clean up with. The scenario
+// context is cancelled by the time they run, so they cannot use it.
+func cleanupContext() context.Context {
+	return context.Background()
+}
+
+// deferCleanup adds a function to undo a change made by a step. Cleanups run
+// in reverse order when the scenario ends, whatever the cleanup policy.
+func (k *kubernetesScenario) deferCleanup(f func(context.Context) error) {
+	k.cleanups = append(k.cleanups, f)
+}
+
+// runCleanups undoes the changes made by steps, then deletes the objects
+// created by the scenario in reverse creation order unless the cleanup
+// policy keeps them, in which case a summary of those left behind is printed
+func (k *kubernetesScenario) runCleanups(ctx context.Context, sc *godog.Scenario, err error) (context.Context, error) {
+	cleanupCtx := cleanupContext()
+
+	var errs []string
+	for i := len(k.cleanups) - 1; i >= 0; i-- {
+		if err := k.cleanups[i](cleanupCtx); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	k.cleanups = nil
+
+	created := k.client.Tracked()
+	if k.keep(err) {
+		k.reportLeftovers(cleanupCtx, sc, created)
+	} else {
+		for i := len(created) - 1; i >= 0; i-- {
+			if err := k.deleteAndWait(cleanupCtx, created[i]); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return ctx, fmt.Errorf("cleanup failed: %s", strings.Join(errs, "; "))
+	}
+	return ctx, nil
+}
+
+// keep reports whether the cleanup policy keeps what a scenario created,
+// given the scenario's error
+func (k *kubernetesScenario) keep(err error) bool {
+	return k.cleanupPolicy == CleanupNever || (k.cleanupPolicy == CleanupOnSuccess && err != nil)
+}
+
+// deleteAndWait deletes an object created by the scenario and waits until
+// it is gone, reporting the finalizers of an object stuck terminating
+func (k *kubernetesScenario) deleteAndWait(ctx context.Context, u *unstructured.Unstructured) error {
+	uid := u.GetUID()
+	err := k.client.Delete(ctx, u, client.Preconditions{UID: &uid})
+	// a conflict means the object was replaced by one the scenario did not create
+	if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var current *unstructured.Unstructured
+	err = wait.PollImmediateWithContext(ctx, time.Second, deletionTimeout, func(ctx context.Context) (bool, error) {
+		current, err = k.existing(ctx, u)
+		if err != nil {
+			return false, err
+		}
+		return current == nil || current.GetUID() != uid, nil
+	})
+	if errors.Is(err, wait.ErrWaitTimeout) && current != nil {
+		msg := fmt.Sprintf("%s %s was not deleted within %s", u.GetKind(), objectName(u), deletionTimeout)
+		if finalizers := current.GetFinalizers(); len(finalizers) > 0 {
+			msg = fmt.Sprintf("%s, blocked by finalizers: %s", msg, strings.Join(finalizers, ", "))
+		}
+		return errors.New(msg)
+	}
+	return err
+}
+
+// reportLeftovers prints the objects created by the scenario which still
+// exist
+func (k *kubernetesScenario) reportLeftovers(ctx context.Context, sc *godog.Scenario, created []*unstructured.Unstructured) {
+	var leftovers []string
+	for _, u := range created {
+		current, err := k.existing(ctx, u)
+		if err == nil && (current == nil || current.GetUID() != u.GetUID()) {
+			continue
+		}
+		leftovers = append(leftovers, fmt.Sprintf("  %s %s", u.GetKind(), objectName(u)))
+	}
+	if len(leftovers) == 0 {
+		return
+	}
+
+	fmt.Fprintf(k.out, "\nKept the resources created by scenario %q (cleanup policy %s):\n%s\n\n",
+		sc.Name, k.cleanupPolicy, strings.Join(leftovers, "\n"))
+}
+
+func objectName(u *unstructured.Unstructured) string {
+	if u.GetNamespace() == "" {
+		return u.GetName()
+	}
+	return u.GetNamespace() + "/" + u.GetName()
+}
@@ -0,0 +1,246 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cucumber/godog"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// collection is a ref to a group of objects which is resolved again each
+// time it is used, so objects created or deleted during a step are seen
+type collection struct {
+	kind string
+	list func(ctx context.Context) ([]*unstructured.Unstructured, error)
+
+	// report describes how the collection was last resolved, if useful
+	report func() string
+}
+
+func (k *kubernetesScenario) AddCollectionSteps(sc *godog.ScenarioContext) {
+	sc.Step(`^the ([a-z][a-z0-9.]*) matching "([^"]*)"(?: in namespace ([a-z0-9][-a-z0-9]*[a-z0-9]))? as `+dns1123Name+`$`, k.theResourcesMatching)
+}
+
+// theResourcesMatching registers a collection of the objects of a kind
+// matching a label selector, in a namespace or across all namespaces
+func (k *kubernetesScenario) theResourcesMatching(kind, selector, namespace, ref string) (err error) {
+	defer failHandler(&err)
+
+	gvk, err := k.mapper.KindFor(schema.ParseGroupResource(kind).WithVersion(""))
+	Expect(err).ShouldNot(HaveOccurred(), "Could not resolve the kind %s", kind)
+
+	sel, err := labels.Parse(selector)
+	Expect(err).ShouldNot(HaveOccurred(), "Invalid label selector %q", selector)
+
+	opts := []client.ListOption{client.MatchingLabelsSelector{Selector: sel}}
+	if namespace != "" {
+		opts = append(opts, client.InNamespace(namespace))
+	}
+
+	k.collections[ref] = &collection{
+		kind: gvk.Kind,
+		list: func(ctx context.Context) ([]*unstructured.Unstructured, error) {
+			return k.listObjects(ctx, gvk, opts...)
+		},
+	}
+
+	return nil
+}
+
+func (k *kubernetesScenario) listObjects(ctx context.Context, gvk schema.GroupVersionKind, opts ...client.ListOption) ([]*unstructured.Unstructured, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := k.List(ctx, list, opts...); err != nil {
+		return nil, err
+	}
+
+	objs := make([]*unstructured.Unstructured, len(list.Items))
+	for i := range list.Items {
+		objs[i] = &list.Items[i]
+	}
+	return objs, nil
+}
+
+func (k *kubernetesScenario) getCollection(ref string) *collection {
+	c, ok := k.collections[ref]
+	Expect(ok).Should(BeTrue(), "No collection called %s was registered in a previous step", ref)
+	return c
+}
+
+// poller polls the objects currently in the collection
+func (c *collection) poller() poller {
+	return func(ctx context.Context) (interface{}, error) {
+		return c.list(ctx)
+	}
+}
+
+// matcher adds the collection's report to the failure messages of m
+func (c *collection) matcher(m types.GomegaMatcher) types.GomegaMatcher {
+	if c.report == nil {
+		return m
+	}
+	return &reportingMatcher{GomegaMatcher: m, report: c.report}
+}
+
+type reportingMatcher struct {
+	types.GomegaMatcher
+	report func() string
+}
+
+func (m *reportingMatcher) FailureMessage(actual interface{}) string {
+	return m.message(m.GomegaMatcher.FailureMessage(actual))
+}
+
+func (m *reportingMatcher) NegatedFailureMessage(actual interface{}) string {
+	return m.message(m.GomegaMatcher.NegatedFailureMessage(actual))
+}
+
+func (m *reportingMatcher) message(message string) string {
+	if r := m.report(); r != "" {
+		return fmt.Sprintf("%s\n%s", message, r)
+	}
+	return message
+}
+
+// pod returns a pod from the collection for steps which act on a single
+// pod, preferring a running pod
+func (c *collection) pod(ctx context.Context) (*unstructured.Unstructured, error) {
+	objs, err := c.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(objs) == 0 {
+		return nil, fmt.Errorf("no %s were found", plural(c.kind))
+	}
+
+	sort.Slice(objs, func(i, j int) bool {
+		return objs[i].GetName() < objs[j].GetName()
+	})
+	for _, o := range objs {
+		if phase, _, _ := unstructured.NestedString(o.Object, "status", "phase"); phase == string(corev1.PodRunning) {
+			return o, nil
+		}
+	}
+	return objs[0], nil
+}
+
+// quantify matches a list of objects where all, or any, of the objects
+// satisfy the matcher
+func quantify(quantifier, kind string, matcher types.GomegaMatcher) types.GomegaMatcher {
+	return &quantifiedMatcher{all: quantifier == "all", kind: kind, matcher: matcher}
+}
+
+type quantifiedMatcher struct {
+	all     bool
+	kind    string
+	matcher types.GomegaMatcher
+
+	total    int
+	failures []string
+}
+
+func (m *quantifiedMatcher) Match(actual interface{}) (bool, error) {
+	objs, ok := actual.([]*unstructured.Unstructured)
+	if !ok {
+		return false, fmt.Errorf("quantifiedMatcher expects a list of objects, got %T", actual)
+	}
+
+	m.total = len(objs)
+	m.failures = nil
+	for _, o := range objs {
+		success, err := m.matcher.Match(o)
+		if err != nil {
+			m.failures = append(m.failures, fmt.Sprintf("%s: %s", o.GetName(), indent(err.Error())))
+			continue
+		}
+		if !success {
+			m.failures = append(m.failures, fmt.Sprintf("%s: %s", o.GetName(), indent(m.matcher.FailureMessage(o))))
+		}
+	}
+
+	if m.all {
+		return m.total > 0 && len(m.failures) == 0, nil
+	}
+	return len(m.failures) < m.total, nil
+}
+
+func (m *quantifiedMatcher) FailureMessage(actual interface{}) string {
+	if m.total == 0 {
+		return fmt.Sprintf("Expected %s %s to match but none were found", m.quantifier(), plural(m.kind))
+	}
+	return fmt.Sprintf("Expected %s of %d %s to match but %d did not:\n  %s",
+		m.quantifier(), m.total, plural(m.kind), len(m.failures), strings.Join(m.failures, "\n  "))
+}
+
+func (m *quantifiedMatcher) NegatedFailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected %s of %d %s not to match", m.quantifier(), m.total, plural(m.kind))
+}
+
+func (m *quantifiedMatcher) quantifier() string {
+	if m.all {
+		return "all"
+	}
+	return "any"
+}
+
+// haveCount matches a list of objects by its length, listing the objects
+// found on failure
+func haveCount(comparator string, n int, kind string) types.GomegaMatcher {
+	return &countMatcher{comparator: comparator, n: n, kind: kind}
+}
+
+type countMatcher struct {
+	comparator string
+	n          int
+	kind       string
+}
+
+func (m *countMatcher) Match(actual interface{}) (bool, error) {
+	objs, ok := actual.([]*unstructured.Unstructured)
+	if !ok {
+		return false, fmt.Errorf("countMatcher expects a list of objects, got %T", actual)
+	}
+	return BeNumerically(m.comparator, m.n).Match(len(objs))
+}
+
+func (m *countMatcher) FailureMessage(actual interface{}) string {
+	return m.message(actual, "")
+}
+
+func (m *countMatcher) NegatedFailureMessage(actual interface{}) string {
+	return m.message(actual, "not ")
+}
+
+func (m *countMatcher) message(actual interface{}, negation string) string {
+	objs, _ := actual.([]*unstructured.Unstructured)
+	names := make([]string, len(objs))
+	for i, o := range objs {
+		names[i] = o.GetName()
+	}
+
+	expected := map[string]string{"==": "", ">=": "at least ", "<=": "at most "}[m.comparator]
+	msg := fmt.Sprintf("Expected %s%s%d %s but found %d", negation, expected, m.n, plural(m.kind), len(objs))
+	if len(names) > 0 {
+		msg = fmt.Sprintf("%s: %s", msg, strings.Join(names, ", "))
+	}
+	return msg
+}
+
+func plural(kind string) string {
+	resource, _ := meta.UnsafeGuessKindToResource(schema.GroupVersionKind{Kind: kind})
+	return resource.Resource
+}
+
+func indent(s string) string {
+	return strings.ReplaceAll(s, "\n", "\n    ")
+}
@@ -3,6 +3,13 @@ package kubernetes
 // const dns1123Name = "([a-z0-9]+[-a-z0-9]*[a-z0-9])"
 const dns1123Name = `(\w+)`
 
+// resourceRef matches a registered ref, or a ref derived from an object's
+// kind and name such as app/deployment/web or deployment/web
+const resourceRef = `(\w+(?:/[-.\w]+){0,2})`
+
+// objectRef is the stricter form of resourceRef used by assertions
+const objectRef = `([a-z0-9][-a-z0-9]*[a-z0-9](?:/[a-z0-9][-a-z0-9.]*[a-z0-9]){0,2})`
+
 // varsubRegex is the regular expression used to validate
 // the var names before substitution
 const varsubRegex = "^[_[:alpha:]][_[:alpha:][:digit:]]*$"
@@ -14,6 +21,7 @@ const (
 	noKindErrMsg       string = "Provided test case resource has an empty Kind"
 	noNameErrMsg       string = "Provided test case resource has an empty Name"
 	notPodErrMsg       string = "Provided resource is not a Pod"
+	resourceSetErrMsg  string = "%s refers to a set of resources, patch one of %s instead"
 )
 
 type podSessionKey struct{}
@@ -2,50 +2,165 @@ package kubernetes
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cucumber/godog"
 	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/yaml"
 )
 
+// crdEstablishTimeout is how long creating a CustomResourceDefinition waits
+// for it to be established
+const crdEstablishTimeout = time.Minute
+
 func (k *kubernetesScenario) AddCRUDSteps(sc *godog.ScenarioContext) {
-	sc.Step(`^I create `+dns1123Name, k.iCreateAResource)
-	sc.Step(`^I patch `+dns1123Name, k.iPatchAResource)
-	sc.Step(`^I delete `+dns1123Name, k.iDeleteAResource)
+	sc.Step(`^I create `+resourceRef, k.iCreateAResource)
+	sc.Step(`^I patch the status of `+resourceRef+`$`, k.iPatchTheStatusOfAResource)
+	sc.Step(`^I patch `+resourceRef, k.iPatchAResource)
+	sc.Step(`^I apply `+resourceRef+` as manager ([-.:\w]+)( with force)?$`, k.iApplyAResource)
+	sc.Step(`^I delete `+resourceRef+`(?: with (foreground|background|orphan) propagation)?(?: (?:with|and) a grace period of (\d+) seconds?)?$`, k.iDeleteAResource)
 }
 
 func (k *kubernetesScenario) iCreateAResource(ctx context.Context, ref string) (err error) {
 	defer failHandler(&err)
 
-	u, ok := k.objRegister[ref]
-	Expect(ok).Should(BeTrue(), noResourceErrMsg, ref)
+	if objs, ok := k.resourceSet(ref); ok {
+		for _, u := range objs {
+			k.create(ctx, u)
+		}
+		return nil
+	}
 
-	Eventually(k.Create).WithContext(ctx).WithArguments(u).Should(Succeed())
+	u := k.lookup(ref)
+
+	k.create(ctx, u)
 
 	return nil
 }
 
+// create creates an object, waiting for a CustomResourceDefinition to be
+// established so custom resources created after it are recognised.
+func (k *kubernetesScenario) create(ctx context.Context, u *unstructured.Unstructured) {
+	Eventually(k.Create).WithContext(ctx).WithArguments(u).Should(Succeed())
+	if u.GroupVersionKind().GroupKind() != crdGroupKind {
+		return
+	}
+
+	Eventually(func() error {
+		crd := &unstructured.Unstructured{}
+		crd.SetGroupVersionKind(u.GroupVersionKind())
+		if err := k.client.Get(ctx, client.ObjectKeyFromObject(u), crd); err != nil {
+			return err
+		}
+		conditions, _, _ := unstructured.NestedSlice(crd.Object, "status", "conditions")
+		for _, c := range conditions {
+			if c, ok := c.(map[string]interface{}); ok && c["type"] == "Established" && c["status"] == "True" {
+				return nil
+			}
+		}
+		return fmt.Errorf("CustomResourceDefinition %s is not established", u.GetName())
+	}).WithContext(ctx).WithTimeout(crdEstablishTimeout).Should(Succeed(), "CustomResourceDefinition %s was not established within %s", u.GetName(), crdEstablishTimeout)
+}
+
 func (k *kubernetesScenario) iPatchAResource(ctx context.Context, ref string, manifest *godog.DocString) (err error) {
 	defer failHandler(&err)
 
-	u, ok := k.objRegister[ref]
-	Expect(ok).Should(BeTrue(), noResourceErrMsg, ref)
+	u := k.patchTarget(ref)
+	patch := parsePatch(manifest)
+	Eventually(k.Patch).WithContext(ctx).WithArguments(u, patch).Should(Succeed())
 
-	patch, err := yaml.YAMLToJSON([]byte(manifest.Content))
-	Expect(err).ShouldNot(HaveOccurred(), patchContentErrMsg, err)
-	Eventually(k.Patch).WithContext(ctx).WithArguments(u, client.RawPatch(types.StrategicMergePatchType, patch)).Should(Succeed())
+	return nil
+}
+
+func (k *kubernetesScenario) iPatchTheStatusOfAResource(ctx context.Context, ref string, manifest *godog.DocString) (err error) {
+	defer failHandler(&err)
+
+	u := k.patchTarget(ref)
+	patch := parsePatch(manifest)
+	Eventually(func() error {
+		return k.client.Status().Patch(ctx, u, patch)
+	}).WithContext(ctx).Should(Succeed())
 
 	return nil
 }
 
-func (k *kubernetesScenario) iDeleteAResource(ctx context.Context, ref string, manifest *godog.DocString) (err error) {
+func (k *kubernetesScenario) patchTarget(ref string) *unstructured.Unstructured {
+	refs, isSet := k.objSets[ref]
+	Expect(isSet).Should(BeFalse(), resourceSetErrMsg, ref, strings.Join(refs, ", "))
+
+	u := k.lookup(ref)
+	return u
+}
+
+// parsePatch chooses the patch type from the media type of the DocString:
+// yaml or json for a JSON merge patch, json-patch for a list of RFC 6902
+// operations, and strategic or none for a strategic merge patch
+func parsePatch(manifest *godog.DocString) client.Patch {
+	Expect(manifest.MediaType).Should(BeElementOf("", "strategic", "yaml", "json", "json-patch"), "Unrecognised content-type %s. Supported types are yaml, json, json-patch, strategic.", manifest.MediaType)
+
+	patch, err := yaml.YAMLToJSON([]byte(strings.ReplaceAll(manifest.Content, "\t", "  ")))
+	Expect(err).ShouldNot(HaveOccurred(), patchContentErrMsg, err)
+
+	switch manifest.MediaType {
+	case "yaml", "json":
+		return client.RawPatch(types.MergePatchType, patch)
+	case "json-patch":
+		var ops []map[string]interface{}
+		Expect(json.Unmarshal(patch, &ops)).Should(Succeed(), patchContentErrMsg, "a json-patch must be a list of operations")
+		return client.RawPatch(types.JSONPatchType, patch)
+	default:
+		return client.RawPatch(types.StrategicMergePatchType, patch)
+	}
+}
+
+func (k *kubernetesScenario) iDeleteAResource(ctx context.Context, ref, propagation, gracePeriod string) (err error) {
 	defer failHandler(&err)
 
-	u, ok := k.objRegister[ref]
-	Expect(ok).Should(BeTrue(), noResourceErrMsg, ref)
-	Eventually(k.Delete).WithContext(ctx).WithArguments(u).Should(Succeed())
+	opts := deleteOptions(propagation, gracePeriod)
+
+	if objs, ok := k.resourceSet(ref); ok {
+		for i := len(objs) - 1; i >= 0; i-- {
+			k.delete(ctx, objs[i], opts...)
+		}
+		return nil
+	}
+
+	u := k.lookup(ref)
+	k.delete(ctx, u, opts...)
 
 	return nil
 }
+
+func (k *kubernetesScenario) delete(ctx context.Context, u *unstructured.Unstructured, opts ...client.DeleteOption) {
+	Eventually(func() error {
+		return k.Delete(ctx, u, opts...)
+	}).WithContext(ctx).Should(Succeed())
+}
+
+// deleteOptions returns the options for a propagation policy of foreground,
+// background or orphan, and a grace period in seconds
+func deleteOptions(propagation, gracePeriod string) []client.DeleteOption {
+	var opts []client.DeleteOption
+	switch propagation {
+	case "foreground":
+		opts = append(opts, client.PropagationPolicy(metav1.DeletePropagationForeground))
+	case "background":
+		opts = append(opts, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	case "orphan":
+		opts = append(opts, client.PropagationPolicy(metav1.DeletePropagationOrphan))
+	}
+	if gracePeriod != "" {
+		seconds, err := strconv.ParseInt(gracePeriod, 10, 64)
+		Expect(err).ShouldNot(HaveOccurred())
+		opts = append(opts, client.GracePeriodSeconds(seconds))
+	}
+	return opts
+}
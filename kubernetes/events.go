@@ -0,0 +1,118 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/onsi/gomega/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// events lists the events whose involved object is the current incarnation
+// of the registered object
+func (k *kubernetesScenario) events(ctx context.Context, u *unstructured.Unstructured) ([]corev1.Event, error) {
+	o, err := k.Object(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &corev1.EventList{}
+	opts := []client.ListOption{client.MatchingFields{"involvedObject.uid": string(o.GetUID())}}
+	if ns := o.GetNamespace(); ns != "" {
+		opts = append(opts, client.InNamespace(ns))
+	}
+	if err := k.List(ctx, list, opts...); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// haveEvent matches a list of events containing an event of the type and
+// reason, when given, whose message satisfies the message matcher
+func haveEvent(eventType, reason string, message types.GomegaMatcher) *eventMatcher {
+	return &eventMatcher{eventType: eventType, reason: reason, message: message}
+}
+
+type eventMatcher struct {
+	eventType string
+	reason    string
+	message   types.GomegaMatcher
+
+	matched []string
+}
+
+func (m *eventMatcher) Match(actual interface{}) (bool, error) {
+	events, ok := actual.([]corev1.Event)
+	if !ok {
+		return false, fmt.Errorf("eventMatcher expects a list of events, got %T", actual)
+	}
+
+	m.matched = nil
+	for _, e := range events {
+		if m.eventType != "" && e.Type != m.eventType {
+			continue
+		}
+		if m.reason != "" && e.Reason != m.reason {
+			continue
+		}
+		if m.message != nil {
+			success, err := m.message.Match(e.Message)
+			if err != nil {
+				return false, err
+			}
+			if !success {
+				continue
+			}
+		}
+		m.matched = append(m.matched, describeEvent(e))
+	}
+	return len(m.matched) > 0, nil
+}
+
+func (m *eventMatcher) FailureMessage(actual interface{}) string {
+	events, _ := actual.([]corev1.Event)
+	article := "a"
+	if m.eventType == "" {
+		article = "an"
+	}
+	msg := fmt.Sprintf("Expected %s %s", article, m.description())
+	if len(events) == 0 {
+		return msg + " but there were no events"
+	}
+
+	seen := make([]string, len(events))
+	for i, e := range events {
+		seen[i] = describeEvent(e)
+	}
+	return fmt.Sprintf("%s but found:\n  %s", msg, strings.Join(seen, "\n  "))
+}
+
+func (m *eventMatcher) NegatedFailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected no %s but found:\n  %s", m.description(), strings.Join(m.matched, "\n  "))
+}
+
+// description describes the expected event, without an article
+func (m *eventMatcher) description() string {
+	d := "event"
+	if m.eventType != "" {
+		d = fmt.Sprintf("%s event", m.eventType)
+	}
+	if m.reason != "" {
+		d = fmt.Sprintf("%s with reason %q", d, m.reason)
+	}
+	if m.message != nil {
+		d += " and a matching message"
+	}
+	return d
+}
+
+func describeEvent(e corev1.Event) string {
+	s := fmt.Sprintf("%s %s: %s", e.Type, e.Reason, e.Message)
+	if e.Count > 1 {
+		s = fmt.Sprintf("%s (x%d)", s, e.Count)
+	}
+	return s
+}
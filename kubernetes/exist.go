@@ -0,0 +1,68 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// existing returns the current state of the object, or nil if it does not
+// exist
+func (k *kubernetesScenario) existing(ctx context.Context, u *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	o := &unstructured.Unstructured{}
+	o.SetGroupVersionKind(u.GroupVersionKind())
+	o.SetName(u.GetName())
+	o.SetNamespace(u.GetNamespace())
+
+	err := k.Get(ctx, o)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// exist succeeds if the object returned by existing is found. The negated
+// failure message lists the finalizers blocking an object's deletion.
+func exist(u *unstructured.Unstructured) *existMatcher {
+	return &existMatcher{kind: u.GetKind(), name: u.GetName()}
+}
+
+type existMatcher struct {
+	kind string
+	name string
+}
+
+func (m *existMatcher) Match(actual interface{}) (bool, error) {
+	o, ok := actual.(*unstructured.Unstructured)
+	if !ok {
+		return false, fmt.Errorf("existMatcher expects an *unstructured.Unstructured, got %T", actual)
+	}
+	return o != nil, nil
+}
+
+func (m *existMatcher) FailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected %s %s to exist", m.kind, m.name)
+}
+
+func (m *existMatcher) NegatedFailureMessage(actual interface{}) string {
+	msg := fmt.Sprintf("Expected %s %s not to exist", m.kind, m.name)
+
+	o, _ := actual.(*unstructured.Unstructured)
+	if o == nil {
+		return msg
+	}
+	if o.GetDeletionTimestamp() == nil {
+		return msg + "\nIt has not been deleted"
+	}
+	msg = fmt.Sprintf("%s\nIt has been deleting since %s", msg, o.GetDeletionTimestamp().UTC().Format("15:04:05"))
+	if finalizers := o.GetFinalizers(); len(finalizers) > 0 {
+		msg = fmt.Sprintf("%s, blocked by finalizers: %s", msg, strings.Join(finalizers, ", "))
+	}
+	return msg
+}
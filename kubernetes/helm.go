@@ -0,0 +1,126 @@
+package kubernetes
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/cucumber/godog"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const defaultReleaseNamespace = "default"
+
+func (k *kubernetesScenario) AddHelmSteps(sc *godog.ScenarioContext) {
+	sc.Step(`^the chart (\S+) rendered as release `+dns1123Name+`(?: in namespace (\S+))? with values:$`, k.theChartRenderedAsRelease)
+}
+
+// theChartRenderedAsRelease templates a local chart as helm template would,
+// without access to a cluster or chart repository. The CRDs in crds/ and the
+// rendered manifests are registered as the release, which can be created
+// with I create <release>. Hooks are left out of the release but are
+// registered under derived refs, e.g. api/job/api-migrate, so they can be
+// created explicitly.
+func (k *kubernetesScenario) theChartRenderedAsRelease(chartPath, release, namespace string, values *godog.DocString) (err error) {
+	defer failHandler(&err)
+
+	Expect(values.MediaType).Should(BeElementOf("", "json", "yaml"), "Unrecognised content-type %s. Supported types are json, yaml.", values.MediaType)
+	if namespace == "" {
+		namespace = k.namespace
+	}
+	if namespace == "" {
+		namespace = defaultReleaseNamespace
+	}
+
+	chrt, err := loader.Load(chartPath)
+	Expect(err).ShouldNot(HaveOccurred(), "Could not load the chart %s", chartPath)
+	Expect(missingDependencies(chrt)).Should(BeEmpty(), "Chart dependencies must be vendored into %s/charts", chartPath)
+
+	vals, err := chartutil.ReadValues([]byte(strings.ReplaceAll(values.Content, "\t", "  ")))
+	Expect(err).ShouldNot(HaveOccurred())
+	Expect(chartutil.ProcessDependencies(chrt, vals)).Should(Succeed())
+
+	caps := chartutil.DefaultCapabilities
+	if chrt.Metadata.KubeVersion != "" {
+		Expect(chartutil.IsCompatibleRange(chrt.Metadata.KubeVersion, caps.KubeVersion.String())).Should(BeTrue(),
+			"Chart requires kubeVersion %s which is incompatible with Kubernetes %s", chrt.Metadata.KubeVersion, caps.KubeVersion.String())
+	}
+
+	options := chartutil.ReleaseOptions{
+		Name:      release,
+		Namespace: namespace,
+		Revision:  1,
+		IsInstall: true,
+	}
+	renderValues, err := chartutil.ToRenderValues(chrt, vals, options, caps)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	files, err := engine.Render(chrt, renderValues)
+	Expect(err).ShouldNot(HaveOccurred(), "Could not render the chart %s", chartPath)
+	for name := range files {
+		if path.Base(name) == "NOTES.txt" {
+			delete(files, name)
+		}
+	}
+
+	hooks, manifests, err := releaseutil.SortManifests(files, caps.APIVersions, releaseutil.InstallOrder)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	var crds []*unstructured.Unstructured
+	for _, crd := range chrt.CRDObjects() {
+		crds = append(crds, k.parseManifest(crd.File.Data)...)
+	}
+	objs := crds
+	for _, m := range manifests {
+		objs = append(objs, k.releaseResources(m.Content, namespace, crds)...)
+	}
+	Expect(objs).ShouldNot(BeEmpty(), "The chart %s did not render any resources", chartPath)
+	k.register(release, objs)
+
+	for _, h := range hooks {
+		for _, u := range k.releaseResources(h.Manifest, namespace, crds) {
+			k.registerDerived(release, u)
+		}
+	}
+
+	return nil
+}
+
+// releaseResources parses a rendered manifest, placing namespaced objects
+// without a namespace in the release namespace as helm install would. The
+// scope of custom resources not installed yet is read from the chart's crds.
+func (k *kubernetesScenario) releaseResources(manifest, namespace string, crds []*unstructured.Unstructured) []*unstructured.Unstructured {
+	objs := k.parseManifest([]byte(manifest))
+	defs := append(objs[:len(objs):len(objs)], crds...)
+	for _, u := range objs {
+		if u.GetNamespace() == "" && k.isNamespaced(u, defs) {
+			u.SetNamespace(namespace)
+		}
+	}
+	return objs
+}
+
+// missingDependencies returns the dependencies declared in Chart.yaml which
+// are not in the chart's charts/ directory
+func missingDependencies(chrt *chart.Chart) []string {
+	var missing []string
+	for _, d := range chrt.Metadata.Dependencies {
+		found := false
+		for _, c := range chrt.Dependencies() {
+			if c.Name() == d.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, fmt.Sprintf("%s (%s)", d.Name, d.Version))
+		}
+	}
+	return missing
+}
@@ -1,20 +1,29 @@
 package kubernetes
 
 import (
+	"context"
 	"io"
 	"strings"
 
 	"github.com/cucumber/godog"
 	"github.com/testernetes/gkube"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/tools/portforward"
 )
 
 type kubernetesScenario struct {
 	gkube.KubernetesHelper
+	client            *TrackingClient
+	mapper            meta.RESTMapper
 	objRegister       map[string]*unstructured.Unstructured
+	objSets           map[string][]string
+	collections       map[string]*collection
 	podPortForwarders map[string]*portforward.PortForwarder
 	podSessions       map[string]*gkube.PodSession
+	cleanups          []func(context.Context) error
+	cleanupPolicy     CleanupPolicy
+	namespace         string
 
 	out    io.Writer
 	errOut io.Writer
@@ -26,10 +35,15 @@ func (k *kubernetesScenario) closePortForwarders() {
 	}
 }
 
-func NewKubernetesScenario(sc *godog.ScenarioContext, helper gkube.KubernetesHelper) kubernetesScenario {
-	ks := kubernetesScenario{
+func NewKubernetesScenario(sc *godog.ScenarioContext, helper gkube.KubernetesHelper, c *TrackingClient, mapper meta.RESTMapper, policy CleanupPolicy) *kubernetesScenario {
+	ks := &kubernetesScenario{
 		KubernetesHelper:  helper,
+		client:            c,
+		mapper:            mapper,
+		cleanupPolicy:     policy,
 		objRegister:       make(map[string]*unstructured.Unstructured),
+		objSets:           make(map[string][]string),
+		collections:       make(map[string]*collection),
 		podPortForwarders: make(map[string]*portforward.PortForwarder),
 		podSessions:       make(map[string]*gkube.PodSession),
 		out:               &strings.Builder{},
@@ -41,6 +55,14 @@ func NewKubernetesScenario(sc *godog.ScenarioContext, helper gkube.KubernetesHel
 	ks.AddPodExtensionSteps(sc)
 	ks.AddAssertSteps(sc)
 	ks.AddResourceSteps(sc)
+	ks.AddCollectionSteps(sc)
+	ks.AddOwnedSteps(sc)
+	ks.AddKustomizeSteps(sc)
+	ks.AddHelmSteps(sc)
+	ks.AddWorkloadSteps(sc)
+	ks.AddNodeSteps(sc)
+
+	sc.After(ks.runCleanups)
 
 	return ks
 }
@@ -0,0 +1,30 @@
+package kubernetes
+
+import (
+	"github.com/cucumber/godog"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+func (k *kubernetesScenario) AddKustomizeSteps(sc *godog.ScenarioContext) {
+	sc.Step(`^the resources rendered from kustomization (\S+)$`, k.theResourcesRenderedFromKustomization)
+}
+
+// theResourcesRenderedFromKustomization builds a kustomization as kustomize
+// build would and registers each object under a ref derived from its kind
+// and name, e.g. deployment/web
+func (k *kubernetesScenario) theResourcesRenderedFromKustomization(path string) (err error) {
+	defer failHandler(&err)
+
+	kustomizer := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+	resMap, err := kustomizer.Run(filesys.MakeFsOnDisk(), path)
+	Expect(err).ShouldNot(HaveOccurred(), "Could not build the kustomization %s", path)
+
+	manifests, err := resMap.AsYaml()
+	Expect(err).ShouldNot(HaveOccurred())
+
+	k.registerEach("", k.parseResources(manifests))
+
+	return nil
+}
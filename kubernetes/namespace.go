@@ -0,0 +1,123 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cucumber/godog"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// NamespaceVariable is the template variable holding the name of an
+	// isolated scenario's namespace
+	NamespaceVariable = "namespace"
+
+	isolatedNamespacePrefix    = "bdk-"
+	namespaceTerminationPeriod = 5 * time.Minute
+)
+
+var crdGroupKind = schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}
+
+// Isolate creates a uniquely named namespace for the scenario with c.
+// Namespaced objects which do not set a namespace are placed in it. It is
+// deleted once the scenario and every After hook registered before it have
+// finished, so objects in it are cleaned up first, unless the cleanup policy
+// keeps them.
+func (k *kubernetesScenario) Isolate(ctx context.Context, sc *godog.ScenarioContext, c client.Client) (rctx context.Context, err error) {
+	rctx = ctx
+	defer failHandler(&err)
+
+	ns := &corev1.Namespace{}
+	ns.SetGenerateName(isolatedNamespacePrefix)
+	Eventually(func() error {
+		return c.Create(ctx, ns)
+	}).WithContext(ctx).Should(Succeed())
+	k.namespace = ns.Name
+
+	sc.After(func(ctx context.Context, s *godog.Scenario, err error) (context.Context, error) {
+		if k.keep(err) {
+			fmt.Fprintf(k.out, "\nKept namespace %s of scenario %q (cleanup policy %s)\n\n", ns.Name, s.Name, k.cleanupPolicy)
+			return ctx, nil
+		}
+		return ctx, deleteNamespace(cleanupContext(), c, ns)
+	})
+
+	return storeVariables(ctx, map[string]string{NamespaceVariable: ns.Name}), nil
+}
+
+// deleteNamespace deletes a namespace and waits for it to terminate
+func deleteNamespace(ctx context.Context, c client.Client, ns *corev1.Namespace) error {
+	if err := c.Delete(ctx, ns); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+
+	err := wait.PollImmediateWithContext(ctx, time.Second, namespaceTerminationPeriod, func(ctx context.Context) (bool, error) {
+		err := c.Get(ctx, client.ObjectKeyFromObject(ns), ns)
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+	if err != nil {
+		return fmt.Errorf("namespace %s did not terminate: %w", ns.Name, err)
+	}
+	return nil
+}
+
+// defaultNamespace places a namespaced object without a namespace in the
+// scenario's isolated namespace
+func (k *kubernetesScenario) defaultNamespace(u *unstructured.Unstructured, defs []*unstructured.Unstructured) {
+	if k.namespace == "" || u.GetNamespace() != "" || !k.isNamespaced(u, defs) {
+		return
+	}
+	u.SetNamespace(k.namespace)
+}
+
+// isNamespaced looks up the scope of an object's kind. A custom resource
+// created alongside its definition is not installed yet, so its scope is
+// read from the definition, either in defs or registered by an earlier step.
+func (k *kubernetesScenario) isNamespaced(u *unstructured.Unstructured, defs []*unstructured.Unstructured) bool {
+	gvk := u.GroupVersionKind()
+	mapping, err := k.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err == nil {
+		return mapping.Scope.Name() == meta.RESTScopeNameNamespace
+	}
+
+	for _, d := range defs {
+		if scope, ok := definedScope(d, gvk.GroupKind()); ok {
+			return scope == "Namespaced"
+		}
+	}
+	for _, d := range k.objRegister {
+		if scope, ok := definedScope(d, gvk.GroupKind()); ok {
+			return scope == "Namespaced"
+		}
+	}
+
+	Expect(err).ShouldNot(HaveOccurred(), "Could not find the scope of %s, it is not installed and its CustomResourceDefinition was not given", gvk.Kind)
+	return false
+}
+
+// definedScope returns the scope d defines for gk, if d is the
+// CustomResourceDefinition of gk
+func definedScope(d *unstructured.Unstructured, gk schema.GroupKind) (string, bool) {
+	if d.GroupVersionKind().GroupKind() != crdGroupKind {
+		return "", false
+	}
+	group, _, _ := unstructured.NestedString(d.Object, "spec", "group")
+	kind, _, _ := unstructured.NestedString(d.Object, "spec", "names", "kind")
+	if group != gk.Group || kind != gk.Kind {
+		return "", false
+	}
+	scope, _, _ := unstructured.NestedString(d.Object, "spec", "scope")
+	return scope, true
+}
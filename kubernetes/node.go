@@ -0,0 +1,213 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// nodeRef is a node by name, or the node a pod is scheduled on
+const nodeRef = `(?:node ([a-z0-9][-.a-z0-9]*)|the node (?:of|running) ` + objectRef + `)`
+
+const mirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+// drainTimeout is how long a drain waits for its pods to be evicted when the
+// step does not give a timeout
+const drainTimeout = 2 * time.Minute
+
+func (k *kubernetesScenario) AddNodeSteps(sc *godog.ScenarioContext) {
+	sc.Step(`^I cordon `+nodeRef+`$`, k.iCordon)
+	sc.Step(`^I drain `+nodeRef+`(?: within (\d+\w{1,2}))?$`, k.iDrain)
+	sc.Step(`^I taint `+nodeRef+` with ([^=:\s]+)(?:=([^:\s]*))?:(NoSchedule|PreferNoSchedule|NoExecute)$`, k.iTaint)
+}
+
+func (k *kubernetesScenario) iCordon(ctx context.Context, name, ref string) (err error) {
+	defer failHandler(&err)
+
+	k.cordon(ctx, k.nodeName(ctx, name, ref))
+
+	return nil
+}
+
+// iDrain cordons the node and evicts its pods as kubectl drain does, leaving
+// DaemonSet and mirror pods in place. Evictions refused by a
+// PodDisruptionBudget are retried until the timeout, and the drain completes
+// once the evicted pods are gone.
+func (k *kubernetesScenario) iDrain(ctx context.Context, name, ref, timeout string) (err error) {
+	defer failHandler(&err)
+
+	d := drainTimeout
+	if timeout != "" {
+		d = parseTimeout(timeout)
+	}
+
+	node := k.nodeName(ctx, name, ref)
+	k.cordon(ctx, node)
+
+	pods := &corev1.PodList{}
+	Eventually(func() error {
+		return k.client.List(ctx, pods, client.MatchingFields{"spec.nodeName": node})
+	}).WithContext(ctx).Should(Succeed())
+
+	var drain []*corev1.Pod
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if _, isMirror := pod.Annotations[mirrorPodAnnotation]; isMirror {
+			continue
+		}
+		if owner := metav1.GetControllerOf(pod); owner != nil && owner.Kind == "DaemonSet" {
+			continue
+		}
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		drain = append(drain, pod)
+	}
+
+	evicted := map[types.UID]bool{}
+	Eventually(func(ctx context.Context) error {
+		var pending []string
+		for _, pod := range drain {
+			if !evicted[pod.UID] {
+				err := k.Evict(ctx, pod)
+				switch {
+				case err == nil || apierrors.IsNotFound(err):
+					evicted[pod.UID] = true
+				case apierrors.IsTooManyRequests(err):
+					pending = append(pending, k.blockedEviction(ctx, pod))
+					continue
+				default:
+					pending = append(pending, fmt.Sprintf("eviction of pod %s/%s failed: %v", pod.Namespace, pod.Name, err))
+					continue
+				}
+			}
+
+			current := &corev1.Pod{}
+			err := k.client.Get(ctx, client.ObjectKeyFromObject(pod), current)
+			if apierrors.IsNotFound(err) || (err == nil && current.UID != pod.UID) {
+				continue
+			}
+			pending = append(pending, fmt.Sprintf("pod %s/%s has not been deleted yet", pod.Namespace, pod.Name))
+		}
+		if len(pending) > 0 {
+			return errors.New(strings.Join(pending, "\n"))
+		}
+		return nil
+	}).WithContext(ctx).WithTimeout(d).Should(Succeed(), "Could not drain node %s within %s", node, d)
+
+	return nil
+}
+
+// blockedEviction describes the PodDisruptionBudgets selecting a pod whose
+// eviction was refused
+func (k *kubernetesScenario) blockedEviction(ctx context.Context, pod *corev1.Pod) string {
+	msg := fmt.Sprintf("eviction of pod %s/%s is blocked by a PodDisruptionBudget", pod.Namespace, pod.Name)
+
+	pdbs := &policyv1.PodDisruptionBudgetList{}
+	if err := k.client.List(ctx, pdbs, client.InNamespace(pod.Namespace)); err != nil {
+		return msg
+	}
+	var blocking []string
+	for _, pdb := range pdbs.Items {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		blocking = append(blocking, fmt.Sprintf("%s (%d disruptions allowed)", pdb.Name, pdb.Status.DisruptionsAllowed))
+	}
+	if len(blocking) == 0 {
+		return msg
+	}
+	return fmt.Sprintf("eviction of pod %s/%s is blocked by PodDisruptionBudget %s", pod.Namespace, pod.Name, strings.Join(blocking, ", "))
+}
+
+func (k *kubernetesScenario) iTaint(ctx context.Context, name, ref, key, value, effect string) (err error) {
+	defer failHandler(&err)
+
+	node := k.nodeName(ctx, name, ref)
+	taint := corev1.Taint{Key: key, Value: value, Effect: corev1.TaintEffect(effect)}
+
+	Eventually(func() error {
+		n := &corev1.Node{}
+		if err := k.client.Get(ctx, types.NamespacedName{Name: node}, n); err != nil {
+			return err
+		}
+		for _, t := range n.Spec.Taints {
+			if t.MatchTaint(&taint) {
+				return StopTrying(fmt.Sprintf("Node %s already has a taint %s:%s", node, key, effect))
+			}
+		}
+		n.Spec.Taints = append(n.Spec.Taints, taint)
+		return k.client.Update(ctx, n)
+	}).WithContext(ctx).Should(Succeed())
+
+	k.deferCleanup(func(ctx context.Context) error {
+		return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			n := &corev1.Node{}
+			if err := k.client.Get(ctx, types.NamespacedName{Name: node}, n); err != nil {
+				return client.IgnoreNotFound(err)
+			}
+			var taints []corev1.Taint
+			for _, t := range n.Spec.Taints {
+				if !t.MatchTaint(&taint) {
+					taints = append(taints, t)
+				}
+			}
+			n.Spec.Taints = taints
+			return k.client.Update(ctx, n)
+		})
+	})
+
+	return nil
+}
+
+// cordon marks a node unschedulable, and uncordons it when the scenario ends
+// unless it was already cordoned
+func (k *kubernetesScenario) cordon(ctx context.Context, node string) {
+	n := &corev1.Node{}
+	Eventually(func() error {
+		return k.client.Get(ctx, types.NamespacedName{Name: node}, n)
+	}).WithContext(ctx).Should(Succeed())
+	if n.Spec.Unschedulable {
+		return
+	}
+
+	Eventually(func() error {
+		return k.client.Patch(ctx, n, client.RawPatch(types.MergePatchType, []byte(`{"spec":{"unschedulable":true}}`)))
+	}).WithContext(ctx).Should(Succeed())
+
+	k.deferCleanup(func(ctx context.Context) error {
+		n := &corev1.Node{}
+		n.SetName(node)
+		return client.IgnoreNotFound(k.client.Patch(ctx, n, client.RawPatch(types.MergePatchType, []byte(`{"spec":{"unschedulable":false}}`))))
+	})
+}
+
+// nodeName returns the node name, or the name of the node the referenced pod
+// is scheduled on
+func (k *kubernetesScenario) nodeName(ctx context.Context, name, ref string) string {
+	if name != "" {
+		return name
+	}
+
+	pod := k.getPodFromRegister(ctx, ref)
+	Eventually(func() error {
+		return k.client.Get(ctx, client.ObjectKeyFromObject(pod), pod)
+	}).WithContext(ctx).Should(Succeed())
+	Expect(pod.Spec.NodeName).ShouldNot(BeEmpty(), "Pod %s is not scheduled to a node", pod.Name)
+
+	return pod.Spec.NodeName
+}
@@ -0,0 +1,65 @@
+package kubernetes
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// installOrder is the order in which the kinds of a resource set are created,
+// based on the order Helm installs them. Namespaces and
+// CustomResourceDefinitions come first so the objects which depend on them
+// can be created. Kinds which are not listed are created last.
+var installOrder = []string{
+	"Namespace",
+	"CustomResourceDefinition",
+	"NetworkPolicy",
+	"ResourceQuota",
+	"LimitRange",
+	"PodSecurityPolicy",
+	"PodDisruptionBudget",
+	"ServiceAccount",
+	"Secret",
+	"ConfigMap",
+	"StorageClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"ClusterRole",
+	"ClusterRoleBinding",
+	"Role",
+	"RoleBinding",
+	"Service",
+	"DaemonSet",
+	"Pod",
+	"ReplicationController",
+	"ReplicaSet",
+	"Deployment",
+	"HorizontalPodAutoscaler",
+	"StatefulSet",
+	"Job",
+	"CronJob",
+	"IngressClass",
+	"Ingress",
+	"APIService",
+	"MutatingWebhookConfiguration",
+	"ValidatingWebhookConfiguration",
+}
+
+// sortByInstallOrder sorts objects into the order they should be created,
+// keeping the manifest order of objects of the same kind
+func sortByInstallOrder(objs []*unstructured.Unstructured) {
+	rank := make(map[string]int, len(installOrder))
+	for i, kind := range installOrder {
+		rank[kind] = i
+	}
+	rankOf := func(u *unstructured.Unstructured) int {
+		if r, ok := rank[u.GetKind()]; ok {
+			return r
+		}
+		return len(installOrder)
+	}
+
+	sort.SliceStable(objs, func(i, j int) bool {
+		return rankOf(objs[i]) < rankOf(objs[j])
+	})
+}
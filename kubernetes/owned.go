@@ -0,0 +1,207 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cucumber/godog"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// maxOwnerDepth limits the walk up ownerReferences in case of a cycle
+const maxOwnerDepth = 10
+
+func (k *kubernetesScenario) AddOwnedSteps(sc *godog.ScenarioContext) {
+	sc.Step(`^the ([a-z][a-z0-9.]*) owned by `+objectRef+` as `+dns1123Name+`$`, k.theResourcesOwnedBy)
+}
+
+// theResourcesOwnedBy registers a collection of the objects of a kind which
+// are owned by a registered object, directly or through other owners, e.g.
+// the pods owned by a deployment through its replicasets
+func (k *kubernetesScenario) theResourcesOwnedBy(kind, ownerRef, ref string) (err error) {
+	defer failHandler(&err)
+
+	gvk, err := k.mapper.KindFor(schema.ParseGroupResource(kind).WithVersion(""))
+	Expect(err).ShouldNot(HaveOccurred(), "Could not resolve the kind %s", kind)
+
+	owner := k.lookup(ownerRef)
+
+	tree := &ownershipTree{}
+	k.collections[ref] = &collection{
+		kind: gvk.Kind,
+		list: func(ctx context.Context) ([]*unstructured.Unstructured, error) {
+			return k.ownedObjects(ctx, owner, gvk, tree)
+		},
+		report: tree.String,
+	}
+
+	return nil
+}
+
+// ownedObjects lists the objects of a kind and keeps those with a chain of
+// ownerReferences leading to the current incarnation of the owner
+func (k *kubernetesScenario) ownedObjects(ctx context.Context, owner *unstructured.Unstructured, gvk schema.GroupVersionKind, tree *ownershipTree) ([]*unstructured.Unstructured, error) {
+	root, err := k.Object(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	rootNode := &ownershipNode{uid: root.GetUID(), kind: owner.GetKind(), name: root.GetName()}
+
+	var opts []client.ListOption
+	if ns := root.GetNamespace(); ns != "" {
+		opts = append(opts, client.InNamespace(ns))
+	}
+	candidates, err := k.listObjects(ctx, gvk, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &ownerWalker{k: k, root: root.GetUID(), owners: map[types.UID]*unstructured.Unstructured{}}
+	nodes := map[types.UID]*ownershipNode{root.GetUID(): rootNode}
+
+	var owned []*unstructured.Unstructured
+	for _, c := range candidates {
+		chain, err := w.chain(ctx, c, 0)
+		if err != nil {
+			return nil, err
+		}
+		if chain == nil {
+			continue
+		}
+		owned = append(owned, c)
+
+		parent := rootNode
+		for _, o := range chain {
+			node, ok := nodes[o.GetUID()]
+			if !ok {
+				node = &ownershipNode{uid: o.GetUID(), kind: o.GetKind(), name: o.GetName()}
+				nodes[o.GetUID()] = node
+				parent.children = append(parent.children, node)
+			}
+			parent = node
+		}
+	}
+
+	tree.set(rootNode)
+	return owned, nil
+}
+
+type ownerWalker struct {
+	k      *kubernetesScenario
+	root   types.UID
+	owners map[types.UID]*unstructured.Unstructured
+}
+
+// chain returns the objects from below the root down to o if o is owned by
+// the root, or nil if it is not
+func (w *ownerWalker) chain(ctx context.Context, o *unstructured.Unstructured, depth int) ([]*unstructured.Unstructured, error) {
+	if depth > maxOwnerDepth {
+		return nil, nil
+	}
+
+	for _, ref := range o.GetOwnerReferences() {
+		if ref.UID == w.root {
+			return []*unstructured.Unstructured{o}, nil
+		}
+
+		owner, err := w.owner(ctx, o.GetNamespace(), ref.APIVersion, ref.Kind, ref.Name, ref.UID)
+		if err != nil {
+			return nil, err
+		}
+		if owner == nil {
+			continue
+		}
+
+		chain, err := w.chain(ctx, owner, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if chain != nil {
+			return append(chain, o), nil
+		}
+	}
+	return nil, nil
+}
+
+// owner fetches an owner once per walk, returning nil if it no longer exists
+// or has been replaced by an object with another UID
+func (w *ownerWalker) owner(ctx context.Context, namespace, apiVersion, kind, name string, uid types.UID) (*unstructured.Unstructured, error) {
+	if o, ok := w.owners[uid]; ok {
+		return o, nil
+	}
+
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(schema.FromAPIVersionAndKind(apiVersion, kind))
+	u.SetName(name)
+	u.SetNamespace(namespace)
+	o, err := w.k.existing(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if o != nil && o.GetUID() != uid {
+		o = nil
+	}
+
+	w.owners[uid] = o
+	return o, nil
+}
+
+// ownershipTree holds the ownership graph found by the last walk, to be
+// shown when an assertion fails
+type ownershipTree struct {
+	lock sync.Mutex
+	root *ownershipNode
+}
+
+type ownershipNode struct {
+	uid      types.UID
+	kind     string
+	name     string
+	children []*ownershipNode
+}
+
+func (t *ownershipTree) set(root *ownershipNode) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.root = root
+}
+
+// String renders the tree, e.g.
+//
+//	Deployment web
+//	└── ReplicaSet web-5d8f9
+//	    ├── Pod web-5d8f9-2xk4p
+//	    └── Pod web-5d8f9-r7b2m
+func (t *ownershipTree) String() string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.root == nil {
+		return ""
+	}
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%s %s", t.root.kind, t.root.name)
+	writeChildren(b, t.root, "")
+	return b.String()
+}
+
+func writeChildren(b *strings.Builder, n *ownershipNode, prefix string) {
+	sort.Slice(n.children, func(i, j int) bool {
+		return n.children[i].name < n.children[j].name
+	})
+	for i, c := range n.children {
+		branch, next := "├── ", "│   "
+		if i == len(n.children)-1 {
+			branch, next = "└── ", "    "
+		}
+		fmt.Fprintf(b, "\n%s%s%s %s", prefix, branch, c.kind, c.name)
+		writeChildren(b, c, prefix+next)
+	}
+}
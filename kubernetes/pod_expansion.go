@@ -7,6 +7,7 @@ import (
 	"github.com/cucumber/godog"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -21,13 +22,17 @@ func (k *kubernetesScenario) AddPodExtensionSteps(sc *godog.ScenarioContext) {
 
 func (k *kubernetesScenario) iEvict(ctx context.Context, ref string) (err error) {
 	defer failHandler(&err)
-	pod := k.getPodFromRegister(ref)
-
-	Eventually(k.Evict).WithContext(ctx).WithArguments(pod).Should(Succeed())
+	pod := k.getPodFromRegister(ctx, ref)
+	k.evict(ctx, pod)
 
 	return nil
 }
 
+// evict uses the eviction API, so a PodDisruptionBudget can refuse it
+func (k *kubernetesScenario) evict(ctx context.Context, pod *corev1.Pod) {
+	Eventually(k.Evict).WithContext(ctx).WithArguments(pod).Should(Succeed())
+}
+
 func (k *kubernetesScenario) iExecScriptInDefaultContainer(ctx context.Context, ref string, script *godog.DocString) (err error) {
 	return k.iExecScriptInContainer(ctx, ref, "", script)
 }
@@ -35,7 +40,7 @@ func (k *kubernetesScenario) iExecScriptInDefaultContainer(ctx context.Context,
 func (k *kubernetesScenario) iExecScriptInContainer(ctx context.Context, ref, container string, script *godog.DocString) (err error) {
 	defer failHandler(&err)
 
-	pod := k.getPodFromRegister(ref)
+	pod := k.getPodFromRegister(ctx, ref)
 
 	cmd := script.Content
 	shell := script.MediaType
@@ -58,7 +63,7 @@ func (k *kubernetesScenario) iExecInDefaultContainer(ctx context.Context, cmd, r
 func (k *kubernetesScenario) iExecInContainer(ctx context.Context, cmd, ref, container string) (err error) {
 	defer failHandler(&err)
 
-	pod := k.getPodFromRegister(ref)
+	pod := k.getPodFromRegister(ctx, ref)
 
 	session, err := k.Exec(ctx, pod, container, []string{"/bin/sh", "-c", cmd}, k.out, k.errOut)
 	Expect(err).ShouldNot(HaveOccurred())
@@ -70,7 +75,7 @@ func (k *kubernetesScenario) iExecInContainer(ctx context.Context, cmd, ref, con
 func (k *kubernetesScenario) iPortForwardPod(ctx context.Context, ref, ports string) (err error) {
 	defer failHandler(&err)
 
-	pod := k.getPodFromRegister(ref)
+	pod := k.getPodFromRegister(ctx, ref)
 
 	forwardedPorts := strings.Split(ports, " ")
 
@@ -81,9 +86,15 @@ func (k *kubernetesScenario) iPortForwardPod(ctx context.Context, ref, ports str
 	return nil
 }
 
-func (k *kubernetesScenario) getPodFromRegister(ref string) *corev1.Pod {
-	u, ok := k.objRegister[ref]
-	Expect(ok).Should(BeTrue(), noResourceErrMsg, ref)
+func (k *kubernetesScenario) getPodFromRegister(ctx context.Context, ref string) *corev1.Pod {
+	var u *unstructured.Unstructured
+	if c, isCollection := k.collections[ref]; isCollection {
+		pod, err := c.pod(ctx)
+		Expect(err).ShouldNot(HaveOccurred())
+		u = pod
+	} else {
+		u = k.lookup(ref)
+	}
 	Expect(u.GroupVersionKind().String()).Should(Equal("/v1, Kind=Pod"))
 
 	pod := &corev1.Pod{}
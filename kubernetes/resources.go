@@ -3,17 +3,22 @@ package kubernetes
 import (
 	"context"
 	"fmt"
-	"os"
+	"strings"
 
 	"github.com/cucumber/godog"
 	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func (k *kubernetesScenario) AddResourceSteps(sc *godog.ScenarioContext) {
 	sc.Step(`^the following variables:$`, k.variables)
-	sc.Step(`^a resource called `+dns1123Name, k.AResource)
 	sc.Step(`^a resource called `+dns1123Name+` from file (.*)`, k.AResourceFromFile)
+	sc.Step(`^a resource called `+dns1123Name, k.AResource)
 	sc.Step(`^the following resources$`, k.resources)
+	sc.Step(`^an existing ([a-z][a-z0-9.]*) called ([a-z0-9][-a-z0-9.]*[a-z0-9])(?: in namespace ([a-z0-9][-a-z0-9]*[a-z0-9]))? as `+dns1123Name+`$`, k.anExistingResource)
+	sc.Step(`^I store `+objectRef+`'s '([^']*)' as ([_[:alpha:]][_[:alpha:][:digit:]]*)$`, k.iStoreAJSONPath)
 }
 
 func (k *kubernetesScenario) variables(ctx context.Context, table *godog.Table) (context.Context, error) {
@@ -39,11 +44,47 @@ func (k *kubernetesScenario) variables(ctx context.Context, table *godog.Table)
 	return ctx, nil
 }
 
+// storeVariables adds values to the variables available to later steps
+func storeVariables(ctx context.Context, values map[string]string) context.Context {
+	if len(values) == 0 {
+		return ctx
+	}
+
+	vars, ok := ctx.Value("variables").(map[string]string)
+	if !ok {
+		vars = make(map[string]string)
+		ctx = context.WithValue(ctx, "variables", vars)
+	}
+	for k, v := range values {
+		vars[k] = v
+	}
+
+	return ctx
+}
+
+func (k *kubernetesScenario) iStoreAJSONPath(ctx context.Context, ref, jsonpath, name string) (rctx context.Context, err error) {
+	rctx = ctx
+	defer failHandler(&err)
+
+	u := k.lookup(ref)
+
+	var value string
+	Eventually(func() error {
+		o, err := k.Object(ctx, u)
+		if err != nil {
+			return err
+		}
+		value, err = evaluateJSONPath(o, jsonpath)
+		return err
+	}).WithContext(ctx).Should(Succeed())
+
+	return storeVariables(ctx, map[string]string{name: value}), nil
+}
+
 func (k *kubernetesScenario) AResourceFromFile(ref, file string) (err error) {
 	defer failHandler(&err)
 
-	content, err := os.ReadFile(file)
-	k.objRegister[ref] = k.parseResource(content)
+	k.register(ref, k.readResources(file))
 
 	return nil
 }
@@ -52,7 +93,7 @@ func (k *kubernetesScenario) AResource(ref string, manifest *godog.DocString) (e
 	defer failHandler(&err)
 
 	Expect(manifest.MediaType).Should(BeElementOf("json", "yaml"), "Unrecognised content-type %s. Supported types are json, yaml.", manifest.MediaType)
-	k.objRegister[ref] = k.parseResource([]byte(manifest.Content))
+	k.register(ref, k.parseResources([]byte(manifest.Content)))
 
 	return nil
 }
@@ -69,12 +110,89 @@ func (k *kubernetesScenario) resources(resources *godog.Table) (err error) {
 		ref := row.Cells[0].Value
 		file := row.Cells[1].Value
 
-		data, err := os.ReadFile(file)
-		Expect(err).ShouldNot(HaveOccurred())
-		Expect(data).ShouldNot(BeEmpty())
-		u := k.parseResource(data)
+		k.register(ref, k.readResources(file))
+	}
+	return nil
+}
+
+// anExistingResource registers an object which was not created by the
+// scenario, such as one created by an operator, so it is not cleaned up.
+// The kind may be any name kubectl accepts, e.g. deployment, deploy or
+// deployments.apps.
+func (k *kubernetesScenario) anExistingResource(kind, name, namespace, ref string) (err error) {
+	defer failHandler(&err)
+
+	gvk, err := k.mapper.KindFor(schema.ParseGroupResource(kind).WithVersion(""))
+	Expect(err).ShouldNot(HaveOccurred(), "Could not resolve the kind %s", kind)
+	mapping, err := k.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	Expect(err).ShouldNot(HaveOccurred())
 
-		k.objRegister[ref] = u
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		Expect(namespace).ShouldNot(BeEmpty(), "%s is namespaced, use an existing %s called %s in namespace <namespace>", gvk.Kind, kind, name)
+	} else {
+		Expect(namespace).Should(BeEmpty(), "%s is not namespaced", gvk.Kind)
 	}
+
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	u.SetName(name)
+	u.SetNamespace(namespace)
+	k.register(ref, []*unstructured.Unstructured{u})
+
 	return nil
 }
+
+// register registers a single object as ref. When a manifest holds several
+// objects each is registered under a ref derived from its kind and name,
+// e.g. app/deployment/web, and ref refers to the whole set.
+func (k *kubernetesScenario) register(ref string, objs []*unstructured.Unstructured) {
+	delete(k.objSets, ref)
+	if len(objs) == 1 {
+		k.objRegister[ref] = objs[0]
+		return
+	}
+
+	refs := k.registerEach(ref, objs)
+	delete(k.objRegister, ref)
+	k.objSets[ref] = refs
+}
+
+// registerEach registers every object with registerDerived and returns their
+// refs, failing if two objects would share a ref
+func (k *kubernetesScenario) registerEach(prefix string, objs []*unstructured.Unstructured) []string {
+	refs := make([]string, len(objs))
+	seen := make(map[string]bool, len(objs))
+	for i, u := range objs {
+		refs[i] = k.registerDerived(prefix, u)
+		Expect(seen[refs[i]]).Should(BeFalse(), "%s is defined more than once", refs[i])
+		seen[refs[i]] = true
+	}
+	return refs
+}
+
+// registerDerived registers an object under prefix/kind/name, or kind/name
+// without a prefix, and returns the ref
+func (k *kubernetesScenario) registerDerived(prefix string, u *unstructured.Unstructured) string {
+	ref := fmt.Sprintf("%s/%s", strings.ToLower(u.GetKind()), u.GetName())
+	if prefix != "" {
+		ref = prefix + "/" + ref
+	}
+	k.objRegister[ref] = u
+	return ref
+}
+
+// resourceSet returns the objects in a set registered as ref in the order
+// they should be created
+func (k *kubernetesScenario) resourceSet(ref string) ([]*unstructured.Unstructured, bool) {
+	refs, ok := k.objSets[ref]
+	if !ok {
+		return nil, false
+	}
+
+	objs := make([]*unstructured.Unstructured, len(refs))
+	for i, r := range refs {
+		objs[i] = k.objRegister[r]
+	}
+	sortByInstallOrder(objs)
+	return objs, true
+}
@@ -0,0 +1,81 @@
+package kubernetes
+
+import (
+	"fmt"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
+	"github.com/testernetes/bdk/assertion"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+)
+
+// beStatus matches an object whose status, computed as kstatus does, is
+// expected. Failures report the computed status and message along with the
+// statuses observed while polling.
+func beStatus(expected status.Status) types.GomegaMatcher {
+	timeline := assertion.NewTimeline()
+	return timeline.Report(&statusMatcher{
+		expected: expected,
+		recorder: timeline.Record(Equal(expected.String())),
+	})
+}
+
+type statusMatcher struct {
+	expected status.Status
+	recorder types.GomegaMatcher
+
+	kind   string
+	name   string
+	result *status.Result
+}
+
+func (m *statusMatcher) Match(actual interface{}) (bool, error) {
+	u, err := toUnstructured(actual)
+	if err != nil {
+		return false, err
+	}
+	m.kind, m.name = u.GetKind(), u.GetName()
+
+	m.result, err = status.Compute(u)
+	if err != nil {
+		return false, err
+	}
+	return m.recorder.Match(m.result.Status.String())
+}
+
+func (m *statusMatcher) FailureMessage(actual interface{}) string {
+	return m.message("")
+}
+
+func (m *statusMatcher) NegatedFailureMessage(actual interface{}) string {
+	return m.message("not ")
+}
+
+func (m *statusMatcher) message(negation string) string {
+	msg := fmt.Sprintf("Expected %s %s %sto be %s", m.kind, m.name, negation, m.expected)
+	if m.result == nil {
+		return msg
+	}
+	msg = fmt.Sprintf("%s but it is %s", msg, m.result.Status)
+	if m.result.Message != "" {
+		msg = fmt.Sprintf("%s: %s", msg, m.result.Message)
+	}
+	return msg
+}
+
+func toUnstructured(obj interface{}) (*unstructured.Unstructured, error) {
+	switch o := obj.(type) {
+	case *unstructured.Unstructured:
+		return o, nil
+	case runtime.Object:
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(o)
+		if err != nil {
+			return nil, err
+		}
+		return &unstructured.Unstructured{Object: content}, nil
+	default:
+		return nil, fmt.Errorf("expected a Kubernetes object, got %T", obj)
+	}
+}
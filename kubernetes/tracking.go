@@ -0,0 +1,63 @@
+package kubernetes
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// TrackingClient is a client.Client which records the objects created
+// through it, in creation order, so a scenario can delete them when it ends
+type TrackingClient struct {
+	client.Client
+
+	lock    sync.Mutex
+	created []*unstructured.Unstructured
+}
+
+func NewTrackingClient(c client.Client) *TrackingClient {
+	return &TrackingClient{Client: c}
+}
+
+// Create implements client.Client. Only a reference to the created object
+// is kept, which is enough to delete it.
+func (c *TrackingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	// typed objects do not always have their GVK set
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return err
+	}
+
+	if err := c.Client.Create(ctx, obj, opts...); err != nil {
+		return err
+	}
+
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	u.SetNamespace(obj.GetNamespace())
+	u.SetName(obj.GetName())
+	u.SetUID(obj.GetUID())
+	c.Track(u)
+	return nil
+}
+
+// Track records an object which was created other than by Create, e.g. by
+// a server-side apply
+func (c *TrackingClient) Track(u *unstructured.Unstructured) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.created = append(c.created, u)
+}
+
+// Tracked returns the objects created so far, oldest first, and stops
+// tracking them
+func (c *TrackingClient) Tracked() []*unstructured.Unstructured {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	created := c.created
+	c.created = nil
+	return created
+}
@@ -1,20 +1,100 @@
 package kubernetes
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"runtime/debug"
 
 	. "github.com/onsi/gomega"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/util/jsonpath"
 	"sigs.k8s.io/yaml"
 )
 
-func (k *kubernetesScenario) parseResource(r []byte) *unstructured.Unstructured {
-	u := &unstructured.Unstructured{}
-	r = bytes.ReplaceAll(r, []byte("\t"), []byte("  "))
-	Expect(yaml.Unmarshal(r, u)).Should(Succeed())
+// parseResources parses every object in a multi-document YAML or JSON
+// manifest, placing namespaced objects without a namespace in the isolated
+// namespace when there is one.
+func (k *kubernetesScenario) parseResources(r []byte) []*unstructured.Unstructured {
+	objs := k.parseManifest(r)
+	for _, u := range objs {
+		k.defaultNamespace(u, objs)
+	}
+	return objs
+}
+
+// parseManifest parses every object in a multi-document YAML or JSON
+// manifest, expanding any List kinds into their items.
+func (k *kubernetesScenario) parseManifest(r []byte) []*unstructured.Unstructured {
+	var objs []*unstructured.Unstructured
+
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(r)))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		Expect(err).ShouldNot(HaveOccurred())
+
+		content := map[string]interface{}{}
+		Expect(yaml.Unmarshal(bytes.ReplaceAll(doc, []byte("\t"), []byte("  ")), &content)).Should(Succeed())
+		if len(content) == 0 {
+			continue
+		}
+
+		u := &unstructured.Unstructured{Object: content}
+		if u.IsList() {
+			Expect(u.EachListItem(func(o runtime.Object) error {
+				item := o.(*unstructured.Unstructured)
+				objs = append(objs, k.validateResource(item))
+				return nil
+			})).Should(Succeed())
+			continue
+		}
+		objs = append(objs, k.validateResource(u))
+	}
+
+	Expect(objs).ShouldNot(BeEmpty(), "No resources were found in the manifest")
+	return objs
+}
+
+// readResources parses the objects in a manifest file, or in every YAML and
+// JSON file in a directory.
+func (k *kubernetesScenario) readResources(path string) []*unstructured.Unstructured {
+	info, err := os.Stat(path)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	files := []string{path}
+	if info.IsDir() {
+		files = nil
+		entries, err := os.ReadDir(path)
+		Expect(err).ShouldNot(HaveOccurred())
+		for _, entry := range entries {
+			switch filepath.Ext(entry.Name()) {
+			case ".yaml", ".yml", ".json":
+				if !entry.IsDir() {
+					files = append(files, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+		Expect(files).ShouldNot(BeEmpty(), "No manifests were found in the directory %s", path)
+	}
+
+	var objs []*unstructured.Unstructured
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		Expect(err).ShouldNot(HaveOccurred())
+		objs = append(objs, k.parseResources(data)...)
+	}
+	return objs
+}
 
+func (k *kubernetesScenario) validateResource(u *unstructured.Unstructured) *unstructured.Unstructured {
 	Expect(u.GetAPIVersion()).ShouldNot(BeEmpty(), noAPIVersionErrMsg)
 	Expect(u.GetKind()).ShouldNot(BeEmpty(), noKindErrMsg)
 	Expect(u.GetName()).ShouldNot(BeEmpty(), noNameErrMsg)
@@ -22,10 +102,41 @@ func (k *kubernetesScenario) parseResource(r []byte) *unstructured.Unstructured
 	return u
 }
 
+// evaluateJSONPath returns the text of a JSONPath template for an object
+func evaluateJSONPath(obj runtime.Object, path string) (string, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return "", err
+	}
+
+	j := jsonpath.New("").AllowMissingKeys(false)
+	if err := j.Parse(path); err != nil {
+		return "", err
+	}
+
+	buf := &bytes.Buffer{}
+	if err := j.Execute(buf, content); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Debug adds stack traces to step failures
+var Debug bool
+
 func failHandler(err *error) {
-	// set a debugging flag to enable stack traces or not
 	if r := recover(); r != nil {
-		*err = fmt.Errorf("%s\n\n%s", r, string(debug.Stack()))
-		//*err = fmt.Errorf("%s", r)
+		if Debug {
+			*err = fmt.Errorf("%s\n\n%s", r, string(debug.Stack()))
+			return
+		}
+		*err = fmt.Errorf("%s", r)
 	}
 }
+
+// lookup returns the object registered as ref
+func (k *kubernetesScenario) lookup(ref string) *unstructured.Unstructured {
+	u, ok := k.objRegister[ref]
+	Expect(ok).Should(BeTrue(), noResourceErrMsg, ref)
+	return u
+}
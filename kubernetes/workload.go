@@ -0,0 +1,333 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cucumber/godog"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+	revisionAnnotation    = "deployment.kubernetes.io/revision"
+)
+
+// rollbackSkippedAnnotations are kept from the Deployment rather than copied
+// from the ReplicaSet when rolling back, as kubectl rollout undo does
+var rollbackSkippedAnnotations = map[string]bool{
+	"kubectl.kubernetes.io/last-applied-configuration": true,
+	revisionAnnotation:                          true,
+	"deployment.kubernetes.io/revision-history": true,
+	"deployment.kubernetes.io/desired-replicas": true,
+	"deployment.kubernetes.io/max-replicas":     true,
+	"deprecated.deployment.rollback.to":         true,
+}
+
+func (k *kubernetesScenario) AddWorkloadSteps(sc *godog.ScenarioContext) {
+	sc.Step(`^I scale `+resourceRef+` to (\d+)$`, k.iScale)
+	sc.Step(`^I restart the rollout of `+resourceRef+`$`, k.iRestartTheRollout)
+	sc.Step(`^I roll back `+resourceRef+`$`, k.iRollBack)
+}
+
+// iScale sets the replicas through the scale subresource, so any kind which
+// implements it can be scaled
+func (k *kubernetesScenario) iScale(ctx context.Context, ref string, replicas int) (err error) {
+	defer failHandler(&err)
+
+	u := k.lookup(ref)
+
+	patch := client.RawPatch(ktypes.MergePatchType, []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas)))
+	scale := &unstructured.Unstructured{}
+	scale.SetGroupVersionKind(schema.GroupVersionKind{Group: "autoscaling", Version: "v1", Kind: "Scale"})
+	Eventually(func() error {
+		return k.client.SubResource("scale").Patch(ctx, u, patch, client.WithSubResourceBody(scale))
+	}).WithContext(ctx).Should(Succeed())
+
+	return nil
+}
+
+// iRestartTheRollout updates the pod template annotation as kubectl rollout
+// restart does
+func (k *kubernetesScenario) iRestartTheRollout(ctx context.Context, ref string) (err error) {
+	defer failHandler(&err)
+
+	u := k.lookup(ref)
+	Expect(u.GetKind()).Should(BeElementOf("Deployment", "StatefulSet", "DaemonSet"), "Only Deployments, StatefulSets and DaemonSets can be restarted")
+
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{
+						restartedAtAnnotation: time.Now().Format(time.RFC3339),
+					},
+				},
+			},
+		},
+	}
+	data, err := json.Marshal(patch)
+	Expect(err).ShouldNot(HaveOccurred())
+	Eventually(k.Patch).WithContext(ctx).WithArguments(u, client.RawPatch(ktypes.MergePatchType, data)).Should(Succeed())
+
+	return nil
+}
+
+// iRollBack rolls back to the previous revision as kubectl rollout undo does
+func (k *kubernetesScenario) iRollBack(ctx context.Context, ref string) (err error) {
+	defer failHandler(&err)
+
+	u := k.lookup(ref)
+
+	var patch client.Patch
+	switch u.GetKind() {
+	case "Deployment":
+		patch = k.deploymentRollback(ctx, u)
+	case "StatefulSet", "DaemonSet":
+		patch = k.controllerRevisionRollback(ctx, u)
+	default:
+		Expect(u.GetKind()).Should(BeElementOf("Deployment", "StatefulSet", "DaemonSet"), "Only Deployments, StatefulSets and DaemonSets can be rolled back")
+	}
+	Eventually(k.Patch).WithContext(ctx).WithArguments(u, patch).Should(Succeed())
+
+	return nil
+}
+
+// deploymentRollback returns a patch restoring the pod template of the
+// ReplicaSet with the previous revision
+func (k *kubernetesScenario) deploymentRollback(ctx context.Context, u *unstructured.Unstructured) client.Patch {
+	o, err := k.Object(ctx, u)
+	Expect(err).ShouldNot(HaveOccurred())
+	deployment := &appsv1.Deployment{}
+	Expect(fromObject(o, deployment)).Should(Succeed())
+	Expect(deployment.Spec.Paused).Should(BeFalse(), "Cannot roll back a paused deployment")
+
+	owned, err := k.ownedObjects(ctx, u, appsv1.SchemeGroupVersion.WithKind("ReplicaSet"), &ownershipTree{})
+	Expect(err).ShouldNot(HaveOccurred())
+
+	revisions := map[int64]*appsv1.ReplicaSet{}
+	for _, obj := range owned {
+		rs := &appsv1.ReplicaSet{}
+		Expect(fromObject(obj, rs)).Should(Succeed())
+		revision, err := strconv.ParseInt(rs.Annotations[revisionAnnotation], 10, 64)
+		if err != nil {
+			continue
+		}
+		revisions[revision] = rs
+	}
+	var keys []int64
+	for r := range revisions {
+		keys = append(keys, r)
+	}
+	rs := revisions[previousRevision(keys)]
+	Expect(rs).ShouldNot(BeNil(), "No rollout history found for deployment %s", u.GetName())
+
+	delete(rs.Spec.Template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+	annotations := map[string]string{}
+	for k, v := range deployment.Annotations {
+		if rollbackSkippedAnnotations[k] {
+			annotations[k] = v
+		}
+	}
+	for k, v := range rs.Annotations {
+		if !rollbackSkippedAnnotations[k] {
+			annotations[k] = v
+		}
+	}
+
+	patch, err := json.Marshal([]interface{}{
+		map[string]interface{}{"op": "replace", "path": "/spec/template", "value": rs.Spec.Template},
+		map[string]interface{}{"op": "replace", "path": "/metadata/annotations", "value": annotations},
+	})
+	Expect(err).ShouldNot(HaveOccurred())
+	return client.RawPatch(ktypes.JSONPatchType, patch)
+}
+
+// controllerRevisionRollback returns the patch stored in the
+// ControllerRevision with the previous revision
+func (k *kubernetesScenario) controllerRevisionRollback(ctx context.Context, u *unstructured.Unstructured) client.Patch {
+	owned, err := k.ownedObjects(ctx, u, appsv1.SchemeGroupVersion.WithKind("ControllerRevision"), &ownershipTree{})
+	Expect(err).ShouldNot(HaveOccurred())
+
+	revisions := map[int64]*appsv1.ControllerRevision{}
+	for _, obj := range owned {
+		cr := &appsv1.ControllerRevision{}
+		Expect(fromObject(obj, cr)).Should(Succeed())
+		revisions[cr.Revision] = cr
+	}
+	var keys []int64
+	for r := range revisions {
+		keys = append(keys, r)
+	}
+	cr := revisions[previousRevision(keys)]
+	Expect(cr).ShouldNot(BeNil(), "No rollout history found for %s %s", u.GetKind(), u.GetName())
+
+	return client.RawPatch(ktypes.StrategicMergePatchType, cr.Data.Raw)
+}
+
+// previousRevision returns the second highest revision, or 0 if there is
+// no history
+func previousRevision(revisions []int64) int64 {
+	if len(revisions) < 2 {
+		return 0
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i] > revisions[j] })
+	return revisions[1]
+}
+
+// rolloutState is the progress of a rollout as reported by kubectl rollout
+// status
+type rolloutState struct {
+	message string
+	done    bool
+}
+
+func (k *kubernetesScenario) rolloutStatus(ctx context.Context, u *unstructured.Unstructured) (rolloutState, error) {
+	o, err := k.Object(ctx, u)
+	if err != nil {
+		return rolloutState{}, err
+	}
+
+	message, done, err := rolloutStatus(o)
+	if err != nil {
+		return rolloutState{}, StopTrying(err.Error())
+	}
+	return rolloutState{message: message, done: done}, nil
+}
+
+// rolloutStatus follows the logic of kubectl rollout status
+func rolloutStatus(o runtime.Object) (string, bool, error) {
+	u, err := toUnstructured(o)
+	if err != nil {
+		return "", false, err
+	}
+
+	switch u.GetKind() {
+	case "Deployment":
+		d := &appsv1.Deployment{}
+		if err := fromObject(u, d); err != nil {
+			return "", false, err
+		}
+		if d.Generation > d.Status.ObservedGeneration {
+			return "Waiting for deployment spec update to be observed", false, nil
+		}
+		for _, c := range d.Status.Conditions {
+			if c.Type == appsv1.DeploymentProgressing && c.Reason == "ProgressDeadlineExceeded" {
+				return "", false, fmt.Errorf("deployment %q exceeded its progress deadline", d.Name)
+			}
+		}
+		// a paused deployment does not progress, so say why it is stuck
+		waiting := func(format string, a ...interface{}) (string, bool, error) {
+			msg := fmt.Sprintf(format, a...)
+			if d.Spec.Paused {
+				msg += ", the deployment is paused"
+			}
+			return msg, false, nil
+		}
+		if d.Spec.Replicas != nil && d.Status.UpdatedReplicas < *d.Spec.Replicas {
+			return waiting("Waiting for deployment %q rollout to finish: %d out of %d new replicas have been updated", d.Name, d.Status.UpdatedReplicas, *d.Spec.Replicas)
+		}
+		if d.Status.Replicas > d.Status.UpdatedReplicas {
+			return waiting("Waiting for deployment %q rollout to finish: %d old replicas are pending termination", d.Name, d.Status.Replicas-d.Status.UpdatedReplicas)
+		}
+		if d.Status.AvailableReplicas < d.Status.UpdatedReplicas {
+			return waiting("Waiting for deployment %q rollout to finish: %d of %d updated replicas are available", d.Name, d.Status.AvailableReplicas, d.Status.UpdatedReplicas)
+		}
+		return fmt.Sprintf("deployment %q successfully rolled out", d.Name), true, nil
+
+	case "DaemonSet":
+		ds := &appsv1.DaemonSet{}
+		if err := fromObject(u, ds); err != nil {
+			return "", false, err
+		}
+		if ds.Spec.UpdateStrategy.Type != appsv1.RollingUpdateDaemonSetStrategyType {
+			return "", false, fmt.Errorf("rollout status is only available for %s strategy type", appsv1.RollingUpdateDaemonSetStrategyType)
+		}
+		if ds.Generation > ds.Status.ObservedGeneration {
+			return "Waiting for daemon set spec update to be observed", false, nil
+		}
+		if ds.Status.UpdatedNumberScheduled < ds.Status.DesiredNumberScheduled {
+			return fmt.Sprintf("Waiting for daemon set %q rollout to finish: %d out of %d new pods have been updated", ds.Name, ds.Status.UpdatedNumberScheduled, ds.Status.DesiredNumberScheduled), false, nil
+		}
+		if ds.Status.NumberAvailable < ds.Status.DesiredNumberScheduled {
+			return fmt.Sprintf("Waiting for daemon set %q rollout to finish: %d of %d updated pods are available", ds.Name, ds.Status.NumberAvailable, ds.Status.DesiredNumberScheduled), false, nil
+		}
+		return fmt.Sprintf("daemon set %q successfully rolled out", ds.Name), true, nil
+
+	case "StatefulSet":
+		sts := &appsv1.StatefulSet{}
+		if err := fromObject(u, sts); err != nil {
+			return "", false, err
+		}
+		if sts.Spec.UpdateStrategy.Type != appsv1.RollingUpdateStatefulSetStrategyType {
+			return "", false, fmt.Errorf("rollout status is only available for %s strategy type", appsv1.RollingUpdateStatefulSetStrategyType)
+		}
+		if sts.Status.ObservedGeneration == 0 || sts.Generation > sts.Status.ObservedGeneration {
+			return "Waiting for statefulset spec update to be observed", false, nil
+		}
+		if sts.Spec.Replicas != nil && sts.Status.ReadyReplicas < *sts.Spec.Replicas {
+			return fmt.Sprintf("Waiting for %d pods to be ready", *sts.Spec.Replicas-sts.Status.ReadyReplicas), false, nil
+		}
+		if ru := sts.Spec.UpdateStrategy.RollingUpdate; ru != nil && ru.Partition != nil && *ru.Partition > 0 {
+			if sts.Spec.Replicas != nil && sts.Status.UpdatedReplicas < *sts.Spec.Replicas-*ru.Partition {
+				return fmt.Sprintf("Waiting for partitioned roll out to finish: %d out of %d new pods have been updated", sts.Status.UpdatedReplicas, *sts.Spec.Replicas-*ru.Partition), false, nil
+			}
+			return fmt.Sprintf("partitioned roll out complete: %d new pods have been updated", sts.Status.UpdatedReplicas), true, nil
+		}
+		if sts.Status.UpdateRevision != sts.Status.CurrentRevision {
+			return fmt.Sprintf("waiting for statefulset rolling update to complete %d pods at revision %s", sts.Status.UpdatedReplicas, sts.Status.UpdateRevision), false, nil
+		}
+		return fmt.Sprintf("statefulset rolling update complete %d pods at revision %s", sts.Status.CurrentReplicas, sts.Status.CurrentRevision), true, nil
+	}
+
+	return "", false, fmt.Errorf("rollout status is only available for Deployments, StatefulSets and DaemonSets, not %s", u.GetKind())
+}
+
+// completeRollout matches a finished rollout, reporting the last progress
+// message when it fails
+func completeRollout(u *unstructured.Unstructured) types.GomegaMatcher {
+	return &rolloutMatcher{kind: u.GetKind(), name: u.GetName()}
+}
+
+type rolloutMatcher struct {
+	kind string
+	name string
+
+	message string
+}
+
+func (m *rolloutMatcher) Match(actual interface{}) (bool, error) {
+	state, ok := actual.(rolloutState)
+	if !ok {
+		return false, fmt.Errorf("rolloutMatcher expects a rolloutState, got %T", actual)
+	}
+	m.message = state.message
+	return state.done, nil
+}
+
+func (m *rolloutMatcher) FailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected the rollout of %s %s to complete: %s", m.kind, m.name, m.message)
+}
+
+func (m *rolloutMatcher) NegatedFailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected the rollout of %s %s not to complete: %s", m.kind, m.name, m.message)
+}
+
+func fromObject(o runtime.Object, into interface{}) error {
+	u, err := toUnstructured(o)
+	if err != nil {
+		return err
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), into)
+}
@@ -0,0 +1,218 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func TestRolloutStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		object  string
+		message string
+		done    bool
+		err     string
+	}{
+		{
+			name: "deployment generation not observed",
+			object: `
+kind: Deployment
+metadata: {name: web, generation: 2}
+spec: {replicas: 3}
+status: {observedGeneration: 1}`,
+			message: "Waiting for deployment spec update to be observed",
+		},
+		{
+			name: "deployment progress deadline exceeded",
+			object: `
+kind: Deployment
+metadata: {name: web, generation: 1}
+spec: {replicas: 3}
+status:
+  observedGeneration: 1
+  conditions: [{type: Progressing, status: "False", reason: ProgressDeadlineExceeded}]`,
+			err: `deployment "web" exceeded its progress deadline`,
+		},
+		{
+			name: "deployment updated fewer than desired",
+			object: `
+kind: Deployment
+metadata: {name: web, generation: 1}
+spec: {replicas: 3}
+status: {observedGeneration: 1, replicas: 3, updatedReplicas: 1}`,
+			message: `Waiting for deployment "web" rollout to finish: 1 out of 3 new replicas have been updated`,
+		},
+		{
+			name: "deployment old replicas pending termination",
+			object: `
+kind: Deployment
+metadata: {name: web, generation: 1}
+spec: {replicas: 3}
+status: {observedGeneration: 1, replicas: 4, updatedReplicas: 3}`,
+			message: `Waiting for deployment "web" rollout to finish: 1 old replicas are pending termination`,
+		},
+		{
+			name: "deployment unavailable",
+			object: `
+kind: Deployment
+metadata: {name: web, generation: 1}
+spec: {replicas: 3}
+status: {observedGeneration: 1, replicas: 3, updatedReplicas: 3, availableReplicas: 2}`,
+			message: `Waiting for deployment "web" rollout to finish: 2 of 3 updated replicas are available`,
+		},
+		{
+			name: "deployment paused",
+			object: `
+kind: Deployment
+metadata: {name: web, generation: 2}
+spec: {replicas: 3, paused: true}
+status: {observedGeneration: 2, replicas: 3, updatedReplicas: 0}`,
+			message: `Waiting for deployment "web" rollout to finish: 0 out of 3 new replicas have been updated, the deployment is paused`,
+		},
+		{
+			name: "deployment complete",
+			object: `
+kind: Deployment
+metadata: {name: web, generation: 1}
+spec: {replicas: 3}
+status: {observedGeneration: 1, replicas: 3, updatedReplicas: 3, availableReplicas: 3}`,
+			message: `deployment "web" successfully rolled out`,
+			done:    true,
+		},
+		{
+			name: "daemon set on delete",
+			object: `
+kind: DaemonSet
+metadata: {name: agent}
+spec: {updateStrategy: {type: OnDelete}}`,
+			err: "rollout status is only available for RollingUpdate strategy type",
+		},
+		{
+			name: "daemon set generation not observed",
+			object: `
+kind: DaemonSet
+metadata: {name: agent, generation: 2}
+spec: {updateStrategy: {type: RollingUpdate}}
+status: {observedGeneration: 1}`,
+			message: "Waiting for daemon set spec update to be observed",
+		},
+		{
+			name: "daemon set updated fewer than desired",
+			object: `
+kind: DaemonSet
+metadata: {name: agent, generation: 1}
+spec: {updateStrategy: {type: RollingUpdate}}
+status: {observedGeneration: 1, desiredNumberScheduled: 3, updatedNumberScheduled: 2}`,
+			message: `Waiting for daemon set "agent" rollout to finish: 2 out of 3 new pods have been updated`,
+		},
+		{
+			name: "daemon set unavailable",
+			object: `
+kind: DaemonSet
+metadata: {name: agent, generation: 1}
+spec: {updateStrategy: {type: RollingUpdate}}
+status: {observedGeneration: 1, desiredNumberScheduled: 3, updatedNumberScheduled: 3, numberAvailable: 1}`,
+			message: `Waiting for daemon set "agent" rollout to finish: 1 of 3 updated pods are available`,
+		},
+		{
+			name: "daemon set complete",
+			object: `
+kind: DaemonSet
+metadata: {name: agent, generation: 1}
+spec: {updateStrategy: {type: RollingUpdate}}
+status: {observedGeneration: 1, desiredNumberScheduled: 3, updatedNumberScheduled: 3, numberAvailable: 3}`,
+			message: `daemon set "agent" successfully rolled out`,
+			done:    true,
+		},
+		{
+			name: "stateful set generation not observed",
+			object: `
+kind: StatefulSet
+metadata: {name: db, generation: 1}
+spec: {replicas: 3, updateStrategy: {type: RollingUpdate}}`,
+			message: "Waiting for statefulset spec update to be observed",
+		},
+		{
+			name: "stateful set not ready",
+			object: `
+kind: StatefulSet
+metadata: {name: db, generation: 1}
+spec: {replicas: 3, updateStrategy: {type: RollingUpdate}}
+status: {observedGeneration: 1, readyReplicas: 1}`,
+			message: "Waiting for 2 pods to be ready",
+		},
+		{
+			name: "stateful set partition updating",
+			object: `
+kind: StatefulSet
+metadata: {name: db, generation: 1}
+spec: {replicas: 3, updateStrategy: {type: RollingUpdate, rollingUpdate: {partition: 1}}}
+status: {observedGeneration: 1, readyReplicas: 3, updatedReplicas: 1}`,
+			message: "Waiting for partitioned roll out to finish: 1 out of 2 new pods have been updated",
+		},
+		{
+			name: "stateful set partition complete",
+			object: `
+kind: StatefulSet
+metadata: {name: db, generation: 1}
+spec: {replicas: 3, updateStrategy: {type: RollingUpdate, rollingUpdate: {partition: 1}}}
+status: {observedGeneration: 1, readyReplicas: 3, updatedReplicas: 2}`,
+			message: "partitioned roll out complete: 2 new pods have been updated",
+			done:    true,
+		},
+		{
+			name: "stateful set revision updating",
+			object: `
+kind: StatefulSet
+metadata: {name: db, generation: 1}
+spec: {replicas: 3, updateStrategy: {type: RollingUpdate}}
+status: {observedGeneration: 1, readyReplicas: 3, updatedReplicas: 1, currentRevision: db-1, updateRevision: db-2}`,
+			message: "waiting for statefulset rolling update to complete 1 pods at revision db-2",
+		},
+		{
+			name: "stateful set complete",
+			object: `
+kind: StatefulSet
+metadata: {name: db, generation: 1}
+spec: {replicas: 3, updateStrategy: {type: RollingUpdate}}
+status: {observedGeneration: 1, readyReplicas: 3, currentReplicas: 3, currentRevision: db-2, updateRevision: db-2}`,
+			message: "statefulset rolling update complete 3 pods at revision db-2",
+			done:    true,
+		},
+		{
+			name: "unsupported kind",
+			object: `
+kind: ReplicaSet
+metadata: {name: web}`,
+			err: "rollout status is only available for Deployments, StatefulSets and DaemonSets, not ReplicaSet",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &unstructured.Unstructured{}
+			if err := yaml.Unmarshal([]byte("apiVersion: apps/v1\n"+tt.object), &u.Object); err != nil {
+				t.Fatal(err)
+			}
+
+			message, done, err := rolloutStatus(u)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if message != tt.message {
+				t.Errorf("expected message %q, got %q", tt.message, message)
+			}
+			if done != tt.done {
+				t.Errorf("expected done to be %t, got %t", tt.done, done)
+			}
+		})
+	}
+}
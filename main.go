@@ -15,11 +15,14 @@ import (
 	messages "github.com/cucumber/messages-go/v16"
 	"github.com/onsi/gomega"
 	"github.com/spf13/pflag"
+	"github.com/testernetes/bdk/assertion"
 	"github.com/testernetes/bdk/format"
 	"github.com/testernetes/bdk/kubernetes"
 	"github.com/testernetes/gkube"
-	"github.com/testernetes/trackedclient"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/restmapper"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 )
@@ -30,6 +33,12 @@ type VarsKey struct{}
 // the var names before substitution
 const varsubRegex = "^[_[:alpha:]][_[:alpha:][:digit:]]*$"
 
+// isolated runs every kubernetes scenario in its own namespace
+var isolated bool
+
+// cleanupPolicy is the default cleanup policy of kubernetes scenarios
+var cleanupPolicy = kubernetes.CleanupAlways
+
 var opts = godog.Options{
 	Output: colors.Colored(os.Stdout),
 	Format: "k8s",
@@ -39,10 +48,29 @@ func main() {
 	godog.BindCommandLineFlags("", &opts)
 
 	name := pflag.String("name", "bdk", "name")
+	listMatchers := pflag.Bool("matchers", false, "list the available assertion matchers and exit")
+	pflag.BoolVar(&kubernetes.Debug, "debug", false, "include stack traces in step failures")
+	pflag.BoolVar(&isolated, "isolated", false, "run every kubernetes scenario in its own namespace, as if tagged @isolated")
+	cleanup := pflag.String("cleanup", string(kubernetes.CleanupAlways), "delete the resources created by a scenario: always, on-success or never. Tags such as @cleanup-never override it")
 
 	pflag.Parse()
 	opts.Paths = pflag.Args()
 
+	var err error
+	cleanupPolicy, err = kubernetes.ParseCleanupPolicy(*cleanup)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if *listMatchers {
+		fmt.Println("Matchers are tried in this order, the first to match a phrase is used:")
+		for _, m := range assertion.Matchers() {
+			fmt.Println(m.Usage)
+		}
+		os.Exit(0)
+	}
+
 	godog.Format("k8s", "Pretty Formatter for kubernetes", format.KubernetesFormatterFunc)
 
 	testSuite := godog.TestSuite{
@@ -69,7 +97,11 @@ func main() {
 			}()
 
 			if isKubernetesScenario(s.Tags) {
-				setupKubernetesScenarioRun(sc)
+				var err error
+				sctx, err = setupKubernetesScenarioRun(sctx, sc, isolated || hasTag(s.Tags, "@isolated"), scenarioCleanupPolicy(s.Tags))
+				if err != nil {
+					return sctx, err
+				}
 			}
 
 			return sctx, nil
@@ -158,15 +190,30 @@ func getvar(ctx context.Context) func(key string) string {
 }
 
 func isKubernetesScenario(tags []*messages.PickleTag) bool {
+	return hasTag(tags, "@kubernetes")
+}
+
+func hasTag(tags []*messages.PickleTag, name string) bool {
 	for t := range tags {
-		if tags[t].Name == "@kubernetes" {
+		if tags[t].Name == name {
 			return true
 		}
 	}
 	return false
 }
 
-func setupKubernetesScenarioRun(sc *godog.ScenarioContext) {
+// scenarioCleanupPolicy returns the policy set by a tag such as
+// @cleanup-on-success, or the default
+func scenarioCleanupPolicy(tags []*messages.PickleTag) kubernetes.CleanupPolicy {
+	for _, p := range kubernetes.CleanupPolicies {
+		if hasTag(tags, "@cleanup-"+string(p)) {
+			return p
+		}
+	}
+	return cleanupPolicy
+}
+
+func setupKubernetesScenarioRun(ctx context.Context, sc *godog.ScenarioContext, isolate bool, policy kubernetes.CleanupPolicy) (context.Context, error) {
 	gomega.RegisterFailHandler(func(message string, _ ...int) {
 		panic(message)
 	})
@@ -177,15 +224,31 @@ func setupKubernetesScenarioRun(sc *godog.ScenarioContext) {
 		Scheme: clientgoscheme.Scheme,
 	}
 
-	objTrackingClient, err := trackedclient.New(cfg, opts)
+	c, err := client.New(cfg, opts)
 	if err != nil {
 		panic(err)
 	}
+	objTrackingClient := kubernetes.NewTrackingClient(c)
 
-	kubernetes.NewKubernetesScenario(sc, gkube.NewKubernetesHelper(gkube.WithClient(objTrackingClient)))
-
-	sc.After(func(ctx context.Context, sc *godog.Scenario, err error) (context.Context, error) {
-		gomega.Expect(objTrackingClient.DeleteAllTracked(context.Background())).Should(gomega.Succeed())
-		return ctx, nil
-	})
+	// Expand short names such as deploy and svc when resolving kinds
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		panic(err)
+	}
+	mapper := restmapper.NewShortcutExpander(objTrackingClient.RESTMapper(), memory.NewMemCacheClient(discoveryClient))
+
+	// the scenario deletes the objects it created when it ends, as the
+	// cleanup policy allows
+	ks := kubernetes.NewKubernetesScenario(sc, gkube.NewKubernetesHelper(gkube.WithClient(objTrackingClient)), objTrackingClient, mapper, policy)
+
+	if isolate {
+		// the namespace is not tracked as it is deleted after the tracked
+		// objects inside it, and only if the cleanup policy allows
+		c, err := client.New(cfg, opts)
+		if err != nil {
+			return ctx, err
+		}
+		return ks.Isolate(ctx, sc, c)
+	}
+	return ctx, nil
 }